@@ -2,7 +2,7 @@
 // versions:
 // - protoc-gen-go-grpc v1.2.0
 // - protoc             v4.23.0
-// source: user.proto
+// source: weather.proto
 
 package pb
 
@@ -23,6 +23,11 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type GetWeatherClient interface {
 	Get(ctx context.Context, in *Request, opts ...grpc.CallOption) (*Response, error)
+	Nowcast(ctx context.Context, in *NowcastRequest, opts ...grpc.CallOption) (*NowcastResponse, error)
+	Compare(ctx context.Context, in *ClimateRequest, opts ...grpc.CallOption) (*ClimateResponse, error)
+	Chart(ctx context.Context, in *ChartRequest, opts ...grpc.CallOption) (*ChartResponse, error)
+	Forecast(ctx context.Context, in *ForecastRequest, opts ...grpc.CallOption) (*ForecastResponse, error)
+	Geocode(ctx context.Context, in *GeocodeRequest, opts ...grpc.CallOption) (*GeocodeResponse, error)
 }
 
 type getWeatherClient struct {
@@ -42,12 +47,62 @@ func (c *getWeatherClient) Get(ctx context.Context, in *Request, opts ...grpc.Ca
 	return out, nil
 }
 
+func (c *getWeatherClient) Nowcast(ctx context.Context, in *NowcastRequest, opts ...grpc.CallOption) (*NowcastResponse, error) {
+	out := new(NowcastResponse)
+	err := c.cc.Invoke(ctx, "/proto.GetWeather/Nowcast", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *getWeatherClient) Compare(ctx context.Context, in *ClimateRequest, opts ...grpc.CallOption) (*ClimateResponse, error) {
+	out := new(ClimateResponse)
+	err := c.cc.Invoke(ctx, "/proto.GetWeather/Compare", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *getWeatherClient) Chart(ctx context.Context, in *ChartRequest, opts ...grpc.CallOption) (*ChartResponse, error) {
+	out := new(ChartResponse)
+	err := c.cc.Invoke(ctx, "/proto.GetWeather/Chart", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *getWeatherClient) Forecast(ctx context.Context, in *ForecastRequest, opts ...grpc.CallOption) (*ForecastResponse, error) {
+	out := new(ForecastResponse)
+	err := c.cc.Invoke(ctx, "/proto.GetWeather/Forecast", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *getWeatherClient) Geocode(ctx context.Context, in *GeocodeRequest, opts ...grpc.CallOption) (*GeocodeResponse, error) {
+	out := new(GeocodeResponse)
+	err := c.cc.Invoke(ctx, "/proto.GetWeather/Geocode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // GetWeatherServer is the server API for GetWeather service.
 // All implementations must embed UnimplementedGetWeatherServer
 // for forward compatibility
 type GetWeatherServer interface {
 	Get(context.Context, *Request) (*Response, error)
-	mustEmbedUnimplementedGetWeatherServer()
+	Nowcast(context.Context, *NowcastRequest) (*NowcastResponse, error)
+	Compare(context.Context, *ClimateRequest) (*ClimateResponse, error)
+	Chart(context.Context, *ChartRequest) (*ChartResponse, error)
+	Forecast(context.Context, *ForecastRequest) (*ForecastResponse, error)
+	Geocode(context.Context, *GeocodeRequest) (*GeocodeResponse, error)
+	//mustEmbedUnimplementedGetWeatherServer()
 }
 
 // UnimplementedGetWeatherServer must be embedded to have forward compatible implementations.
@@ -57,6 +112,21 @@ type UnimplementedGetWeatherServer struct {
 func (UnimplementedGetWeatherServer) Get(context.Context, *Request) (*Response, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
 }
+func (UnimplementedGetWeatherServer) Nowcast(context.Context, *NowcastRequest) (*NowcastResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Nowcast not implemented")
+}
+func (UnimplementedGetWeatherServer) Compare(context.Context, *ClimateRequest) (*ClimateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Compare not implemented")
+}
+func (UnimplementedGetWeatherServer) Chart(context.Context, *ChartRequest) (*ChartResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Chart not implemented")
+}
+func (UnimplementedGetWeatherServer) Forecast(context.Context, *ForecastRequest) (*ForecastResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Forecast not implemented")
+}
+func (UnimplementedGetWeatherServer) Geocode(context.Context, *GeocodeRequest) (*GeocodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Geocode not implemented")
+}
 func (UnimplementedGetWeatherServer) mustEmbedUnimplementedGetWeatherServer() {}
 
 // UnsafeGetWeatherServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +158,96 @@ func _GetWeather_Get_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GetWeather_Nowcast_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NowcastRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetWeatherServer).Nowcast(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GetWeather/Nowcast",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetWeatherServer).Nowcast(ctx, req.(*NowcastRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetWeather_Compare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClimateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetWeatherServer).Compare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GetWeather/Compare",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetWeatherServer).Compare(ctx, req.(*ClimateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetWeather_Chart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChartRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetWeatherServer).Chart(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GetWeather/Chart",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetWeatherServer).Chart(ctx, req.(*ChartRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetWeather_Forecast_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForecastRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetWeatherServer).Forecast(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GetWeather/Forecast",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetWeatherServer).Forecast(ctx, req.(*ForecastRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetWeather_Geocode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GeocodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GetWeatherServer).Geocode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.GetWeather/Geocode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GetWeatherServer).Geocode(ctx, req.(*GeocodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // GetWeather_ServiceDesc is the grpc.ServiceDesc for GetWeather service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,7 +259,27 @@ var GetWeather_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Get",
 			Handler:    _GetWeather_Get_Handler,
 		},
+		{
+			MethodName: "Nowcast",
+			Handler:    _GetWeather_Nowcast_Handler,
+		},
+		{
+			MethodName: "Compare",
+			Handler:    _GetWeather_Compare_Handler,
+		},
+		{
+			MethodName: "Chart",
+			Handler:    _GetWeather_Chart_Handler,
+		},
+		{
+			MethodName: "Forecast",
+			Handler:    _GetWeather_Forecast_Handler,
+		},
+		{
+			MethodName: "Geocode",
+			Handler:    _GetWeather_Geocode_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
-	Metadata: "user.proto",
+	Metadata: "weather.proto",
 }
@@ -0,0 +1,134 @@
+package alert
+
+import (
+	"context"
+	"log"
+	"strings"
+	"telegram_service/internal/service"
+	"time"
+)
+
+// CheckFunc fetches the current conditions in a city. Rain is only needed
+// when a rain rule watches the city.
+type CheckFunc func(ctx context.Context, city string, rain bool) (service.Conditions, error)
+
+// NotifyFunc sends the alert of a rule to its chat.
+type NotifyFunc func(ctx context.Context, r Rule, c service.Conditions) error
+
+// Monitor checks the rules every interval. A rule alerts when its condition
+// starts, and again only after the condition has ended and cooldown has
+// passed. Conditions starting during the chat's quiet hours are reported
+// when the quiet hours end, if they still last.
+type Monitor struct {
+	store    *Store
+	check    CheckFunc
+	notify   NotifyFunc
+	interval time.Duration
+	cooldown time.Duration
+	now      func() time.Time
+}
+
+func NewMonitor(store *Store, check CheckFunc, notify NotifyFunc, interval, cooldown time.Duration) *Monitor {
+	return &Monitor{
+		store:    store,
+		check:    check,
+		notify:   notify,
+		interval: interval,
+		cooldown: cooldown,
+		now:      time.Now,
+	}
+}
+
+// Start checks the rules until ctx is done.
+func (m *Monitor) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Monitor) tick(ctx context.Context) {
+	cities := map[string][]Rule{}
+	var order []string
+	for _, r := range m.store.Rules(0) {
+		city := strings.ToLower(r.City)
+		if _, ok := cities[city]; !ok {
+			order = append(order, city)
+		}
+		cities[city] = append(cities[city], r)
+	}
+
+	for _, city := range order {
+		if ctx.Err() != nil {
+			return
+		}
+
+		rules := cities[city]
+		rain := false
+		for _, r := range rules {
+			rain = rain || r.Kind == KindRain
+		}
+
+		c, err := m.check(ctx, rules[0].City, rain)
+		if err != nil {
+			log.Printf("Failed to check alerts for %s: %v", rules[0].City, err)
+			continue
+		}
+
+		for _, r := range rules {
+			m.evaluate(ctx, r, c)
+		}
+	}
+}
+
+func (m *Monitor) evaluate(ctx context.Context, r Rule, c service.Conditions) {
+	now := m.now()
+
+	if !r.Matches(c) {
+		if r.Active {
+			m.setActive(r, false, time.Time{})
+		}
+		return
+	}
+	if r.Active || now.Sub(r.LastAlert) < m.cooldown {
+		return
+	}
+	if m.quiet(r, now) {
+		return
+	}
+
+	err := m.notify(ctx, r, c)
+	if err != nil {
+		log.Printf("Failed to send alert to chat %d: %v", r.ChatID, err)
+		return
+	}
+	m.setActive(r, true, now)
+}
+
+func (m *Monitor) quiet(r Rule, now time.Time) bool {
+	q, ok := m.store.Quiet(r.ChatID)
+	if !ok {
+		return false
+	}
+
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return q.Contains(now.In(loc))
+}
+
+func (m *Monitor) setActive(r Rule, active bool, at time.Time) {
+	err := m.store.SetActive(r, active, at)
+	if err != nil {
+		log.Printf("Failed to save alert state for chat %d: %v", r.ChatID, err)
+	}
+}
@@ -0,0 +1,99 @@
+package alert
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"telegram_service/internal/service"
+	"testing"
+	"time"
+)
+
+func TestMonitor(t *testing.T) {
+	store, err := NewStore(filepath.Join(t.TempDir(), "alerts.json"))
+	require.NoError(t, err)
+	require.NoError(t, store.Add(Rule{ChatID: 1, City: "Minsk", Kind: KindFrost, Threshold: 0, Timezone: "UTC"}))
+	require.NoError(t, store.Add(Rule{ChatID: 2, City: "minsk", Kind: KindWind, Threshold: 15, Timezone: "UTC"}))
+
+	conditions := service.Conditions{Temp: 5, WindSpeed: 5}
+	var checks int
+	var sent []int64
+
+	m := NewMonitor(store, func(ctx context.Context, city string, rain bool) (service.Conditions, error) {
+		checks++
+		assert.False(t, rain)
+		return conditions, nil
+	}, func(ctx context.Context, r Rule, c service.Conditions) error {
+		sent = append(sent, r.ChatID)
+		return nil
+	}, time.Minute, time.Hour)
+
+	now := time.Date(2023, time.May, 10, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	tick := func(after time.Duration) {
+		now = now.Add(after)
+		m.tick(context.Background())
+	}
+
+	tick(0)
+	assert.Equal(t, 1, checks, "one check per city")
+	assert.Empty(t, sent)
+
+	conditions = service.Conditions{Temp: -1, WindSpeed: 20}
+	tick(time.Minute)
+	assert.Equal(t, []int64{1, 2}, sent)
+
+	tick(time.Minute)
+	assert.Equal(t, []int64{1, 2}, sent, "a lasting condition alerts once")
+
+	conditions = service.Conditions{Temp: 1, WindSpeed: 20}
+	tick(time.Minute)
+	conditions = service.Conditions{Temp: -1, WindSpeed: 20}
+	tick(time.Minute)
+	assert.Equal(t, []int64{1, 2}, sent, "a condition flapping within cooldown alerts once")
+
+	conditions = service.Conditions{Temp: 1, WindSpeed: 20}
+	tick(time.Hour)
+	conditions = service.Conditions{Temp: -1, WindSpeed: 20}
+	tick(time.Minute)
+	assert.Equal(t, []int64{1, 2, 1}, sent)
+
+	// Frost starts again during quiet hours and is reported when they end.
+	require.NoError(t, store.SetQuiet(1, &Quiet{From: 22 * 60, To: 7 * 60}))
+	conditions = service.Conditions{Temp: 1, WindSpeed: 20}
+	tick(time.Minute)
+	now = time.Date(2023, time.May, 11, 2, 0, 0, 0, time.UTC)
+	conditions = service.Conditions{Temp: -3, WindSpeed: 20}
+	tick(0)
+	assert.Equal(t, []int64{1, 2, 1}, sent)
+	now = time.Date(2023, time.May, 11, 7, 0, 0, 0, time.UTC)
+	tick(0)
+	assert.Equal(t, []int64{1, 2, 1, 1}, sent)
+}
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	store, err := NewStore(path)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Add(Rule{ChatID: 1, City: "Minsk", Kind: KindFrost, Threshold: 0}))
+	require.NoError(t, store.Add(Rule{ChatID: 1, City: "MINSK", Kind: KindFrost, Threshold: -5}))
+	require.NoError(t, store.Add(Rule{ChatID: 2, City: "Brest", Kind: KindRain, Threshold: 4}))
+	require.NoError(t, store.SetQuiet(1, &Quiet{From: 60, To: 120}))
+
+	restarted, err := NewStore(path)
+	require.NoError(t, err)
+	rules := restarted.Rules(1)
+	require.Len(t, rules, 1, "a rule of the same kind and city is replaced")
+	assert.Equal(t, -5.0, rules[0].Threshold)
+	assert.Len(t, restarted.Rules(0), 2)
+	_, ok := restarted.Quiet(1)
+	assert.True(t, ok)
+
+	ok, err = restarted.Remove(1, KindFrost, "minsk")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = restarted.Remove(1, KindFrost, "minsk")
+	assert.False(t, ok)
+}
@@ -0,0 +1,148 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"telegram_service/internal/service"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindFrost Kind = "frost"
+	KindRain  Kind = "rain"
+	KindWind  Kind = "wind"
+)
+
+// Kinds lists the supported alerts with their default thresholds.
+var Kinds = map[Kind]float64{
+	KindFrost: 0,
+	KindRain:  4,
+	KindWind:  15,
+}
+
+func ParseKind(s string) (Kind, error) {
+	k := Kind(strings.ToLower(s))
+	if _, ok := Kinds[k]; !ok {
+		return "", fmt.Errorf("unknown alert %q, use frost, rain or wind", s)
+	}
+	return k, nil
+}
+
+// Unit is what the threshold of the kind is measured in.
+func (k Kind) Unit() string {
+	switch k {
+	case KindFrost:
+		return "°C"
+	case KindRain:
+		return "mm/h"
+	}
+	return "m/s"
+}
+
+// Rule warns a chat when a condition starts in a city. Active is set while
+// the condition lasts, so it is reported once.
+type Rule struct {
+	ChatID    int64     `json:"chat_id"`
+	City      string    `json:"city"`
+	Kind      Kind      `json:"kind"`
+	Threshold float64   `json:"threshold"`
+	Timezone  string    `json:"timezone"`
+	Active    bool      `json:"active"`
+	LastAlert time.Time `json:"last_alert,omitempty"`
+}
+
+func (r Rule) key() string {
+	return fmt.Sprintf("%d/%s/%s", r.ChatID, r.Kind, strings.ToLower(r.City))
+}
+
+// Value picks the conditions the rule watches.
+func (r Rule) Value(c service.Conditions) float64 {
+	switch r.Kind {
+	case KindFrost:
+		return c.Temp
+	case KindRain:
+		return c.Rain
+	}
+	return c.WindSpeed
+}
+
+func (r Rule) Matches(c service.Conditions) bool {
+	if r.Kind == KindFrost {
+		return r.Value(c) <= r.Threshold
+	}
+	return r.Value(c) >= r.Threshold
+}
+
+func (r Rule) String() string {
+	sign := "≥"
+	if r.Kind == KindFrost {
+		sign = "≤"
+	}
+	return fmt.Sprintf("%s in %s: %s %g %s", r.Kind, r.City, sign, r.Threshold, r.Kind.Unit())
+}
+
+// Message is what the chat gets when the condition starts.
+func (r Rule) Message(c service.Conditions) string {
+	var what string
+	switch r.Kind {
+	case KindFrost:
+		what = "Frost"
+	case KindRain:
+		what = "Heavy rain"
+	default:
+		what = "Strong wind"
+	}
+	return fmt.Sprintf("⚠️ %s in %s: %.1f %s (your threshold is %g %s)",
+		what, r.City, r.Value(c), r.Kind.Unit(), r.Threshold, r.Kind.Unit())
+}
+
+// Quiet hours run from From to To local time and may span midnight.
+type Quiet struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// ParseQuiet parses a range like "22:00-07:00".
+func ParseQuiet(s string) (Quiet, error) {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return Quiet{}, fmt.Errorf("%q is not a range like 22:00-07:00", s)
+	}
+
+	var q Quiet
+	var err error
+	q.From, err = minutes(from)
+	if err == nil {
+		q.To, err = minutes(to)
+	}
+	if err != nil {
+		return Quiet{}, err
+	}
+	if q.From == q.To {
+		return Quiet{}, fmt.Errorf("quiet hours %q are empty", s)
+	}
+	return q, nil
+}
+
+func minutes(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time like 07:30", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains reports whether the local time t is within the quiet hours.
+func (q Quiet) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if q.From < q.To {
+		return m >= q.From && m < q.To
+	}
+	return m >= q.From || m < q.To
+}
+
+func (q Quiet) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", q.From/60, q.From%60, q.To/60, q.To%60)
+}
@@ -0,0 +1,69 @@
+package alert
+
+import (
+	"github.com/stretchr/testify/assert"
+	"telegram_service/internal/service"
+	"testing"
+	"time"
+)
+
+func TestRule_Matches(t *testing.T) {
+	c := service.Conditions{Temp: -2, WindSpeed: 12, Rain: 6}
+
+	var useCase = []struct {
+		Name      string
+		Kind      Kind
+		Threshold float64
+		Matches   bool
+	}{
+		{Name: "Frost", Kind: KindFrost, Threshold: 0, Matches: true},
+		{Name: "Not cold enough", Kind: KindFrost, Threshold: -5, Matches: false},
+		{Name: "Heavy rain", Kind: KindRain, Threshold: 4, Matches: true},
+		{Name: "Light rain", Kind: KindRain, Threshold: 8, Matches: false},
+		{Name: "Wind at threshold", Kind: KindWind, Threshold: 12, Matches: true},
+		{Name: "Calm", Kind: KindWind, Threshold: 15, Matches: false},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			r := Rule{Kind: us.Kind, Threshold: us.Threshold, City: "Minsk"}
+			assert.Equal(t, us.Matches, r.Matches(c))
+		})
+	}
+
+	assert.Equal(t, "⚠️ Frost in Minsk: -2.0 °C (your threshold is 0 °C)", Rule{Kind: KindFrost, City: "Minsk"}.Message(c))
+	assert.Equal(t, "wind in Minsk: ≥ 15 m/s", Rule{Kind: KindWind, City: "Minsk", Threshold: 15}.String())
+}
+
+func TestParseKind(t *testing.T) {
+	k, err := ParseKind("Frost")
+	assert.NoError(t, err)
+	assert.Equal(t, KindFrost, k)
+
+	_, err = ParseKind("hail")
+	assert.Error(t, err)
+}
+
+func TestQuiet(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2023, time.May, 10, hour, minute, 0, 0, time.UTC)
+	}
+
+	night, err := ParseQuiet("22:00-07:00")
+	assert.NoError(t, err)
+	assert.Equal(t, "22:00-07:00", night.String())
+	assert.True(t, night.Contains(at(23, 0)))
+	assert.True(t, night.Contains(at(6, 59)))
+	assert.False(t, night.Contains(at(7, 0)))
+	assert.False(t, night.Contains(at(12, 0)))
+
+	lunch, err := ParseQuiet("12:00 - 13:30")
+	assert.NoError(t, err)
+	assert.True(t, lunch.Contains(at(13, 0)))
+	assert.False(t, lunch.Contains(at(13, 30)))
+
+	for _, s := range []string{"22:00", "22:00-25:00", "07:00-07:00"} {
+		_, err = ParseQuiet(s)
+		assert.Error(t, err, s)
+	}
+}
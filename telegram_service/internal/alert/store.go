@@ -0,0 +1,116 @@
+package alert
+
+import (
+	"sort"
+	"sync"
+	"telegram_service/internal/storage"
+	"time"
+)
+
+type data struct {
+	Rules map[string]Rule `json:"rules"`
+	Quiet map[int64]Quiet `json:"quiet"`
+}
+
+// Store keeps the alert rules and quiet hours in a JSON file.
+type Store struct {
+	mu   sync.Mutex
+	data data
+	path string
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path}
+
+	err := storage.ReadJSON(path, &s.data)
+	if err != nil {
+		return nil, err
+	}
+	if s.data.Rules == nil {
+		s.data.Rules = map[string]Rule{}
+	}
+	if s.data.Quiet == nil {
+		s.data.Quiet = map[int64]Quiet{}
+	}
+	return s, nil
+}
+
+// Add replaces the chat's rule of the same kind for the city.
+func (s *Store) Add(r Rule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Rules[r.key()] = r
+	return s.save()
+}
+
+// Remove reports whether the rule existed.
+func (s *Store) Remove(chatID int64, kind Kind, city string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := Rule{ChatID: chatID, Kind: kind, City: city}.key()
+	if _, ok := s.data.Rules[key]; !ok {
+		return false, nil
+	}
+	delete(s.data.Rules, key)
+	return true, s.save()
+}
+
+// Rules returns the rules of the chat, or of every chat when chatID is 0.
+func (s *Store) Rules(chatID int64) []Rule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]Rule, 0, len(s.data.Rules))
+	for _, r := range s.data.Rules {
+		if chatID == 0 || r.ChatID == chatID {
+			res = append(res, r)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].key() < res[j].key() })
+	return res
+}
+
+// SetActive records whether the rule's condition is on. Alerting sets at.
+func (s *Store) SetActive(r Rule, active bool, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.data.Rules[r.key()]
+	if !ok {
+		return nil
+	}
+	stored.Active = active
+	if !at.IsZero() {
+		stored.LastAlert = at
+	}
+	s.data.Rules[r.key()] = stored
+	return s.save()
+}
+
+func (s *Store) Quiet(chatID int64) (Quiet, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q, ok := s.data.Quiet[chatID]
+	return q, ok
+}
+
+// SetQuiet sets the chat's quiet hours, or removes them when q is nil.
+func (s *Store) SetQuiet(chatID int64, q *Quiet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if q == nil {
+		delete(s.data.Quiet, chatID)
+	} else {
+		s.data.Quiet[chatID] = *q
+	}
+	return s.save()
+}
+
+// save writes the data. The caller holds the lock.
+func (s *Store) save() error {
+	return storage.WriteJSON(s.path, s.data)
+}
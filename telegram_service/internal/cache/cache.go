@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// Cache keeps values for ttl. When it holds size entries, expired ones are
+// dropped first and then the ones closest to expiry.
+type Cache[V any] struct {
+	mu      sync.Mutex
+	entries map[string]entry[V]
+	ttl     time.Duration
+	size    int
+	now     func() time.Time
+}
+
+func New[V any](ttl time.Duration, size int) *Cache[V] {
+	return &Cache[V]{
+		entries: map[string]entry[V]{},
+		ttl:     ttl,
+		size:    size,
+		now:     time.Now,
+	}
+}
+
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expires) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (c *Cache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		c.evict(now)
+	}
+	c.entries[key] = entry[V]{value: value, expires: now.Add(c.ttl)}
+}
+
+// evict makes room for one entry. The caller holds the lock.
+func (c *Cache[V]) evict(now time.Time) {
+	var oldest string
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+			oldest = key
+		}
+	}
+	if len(c.entries) >= c.size {
+		delete(c.entries, oldest)
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2023, time.May, 10, 12, 0, 0, 0, time.UTC)
+	c := New[string](time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	_, ok := c.Get("minsk")
+	assert.False(t, ok)
+
+	c.Set("minsk", "sunny")
+	v, ok := c.Get("minsk")
+	assert.True(t, ok)
+	assert.Equal(t, "sunny", v)
+
+	now = now.Add(time.Minute)
+	_, ok = c.Get("minsk")
+	assert.False(t, ok, "expired")
+}
+
+func TestCache_Evicts(t *testing.T) {
+	now := time.Date(2023, time.May, 10, 12, 0, 0, 0, time.UTC)
+	c := New[int](time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1)
+	now = now.Add(time.Second)
+	c.Set("b", 2)
+	c.Set("b", 3)
+	now = now.Add(time.Second)
+	c.Set("c", 4)
+
+	_, ok := c.Get("a")
+	assert.False(t, ok, "the entry closest to expiry goes first")
+	v, _ := c.Get("b")
+	assert.Equal(t, 3, v)
+	v, _ = c.Get("c")
+	assert.Equal(t, 4, v)
+	assert.Len(t, c.entries, 2)
+}
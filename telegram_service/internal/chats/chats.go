@@ -0,0 +1,84 @@
+// Package chats remembers every chat that has talked to the bot.
+package chats
+
+import (
+	"sort"
+	"sync"
+	"telegram_service/internal/storage"
+	"time"
+)
+
+// Store keeps the chats with the time they were first seen in a JSON file.
+type Store struct {
+	mu    sync.Mutex
+	chats map[int64]time.Time
+	path  string
+	now   func() time.Time
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		chats: map[int64]time.Time{},
+		path:  path,
+		now:   time.Now,
+	}
+
+	err := storage.ReadJSON(path, &s.chats)
+	if err != nil {
+		return nil, err
+	}
+	if s.chats == nil {
+		s.chats = map[int64]time.Time{}
+	}
+	return s, nil
+}
+
+// Add remembers the chat. Only new chats are written to the file.
+func (s *Store) Add(chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chats[chatID]; ok {
+		return nil
+	}
+	s.chats[chatID] = s.now()
+	return storage.WriteJSON(s.path, s.chats)
+}
+
+// Remove forgets a chat, one that blocked the bot for example.
+func (s *Store) Remove(chatID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.chats[chatID]; !ok {
+		return nil
+	}
+	delete(s.chats, chatID)
+	return storage.WriteJSON(s.path, s.chats)
+}
+
+// List returns the chats, oldest first.
+func (s *Store) List() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int64, 0, len(s.chats))
+	for id := range s.chats {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := s.chats[ids[i]], s.chats[ids[j]]
+		if a.Equal(b) {
+			return ids[i] < ids[j]
+		}
+		return a.Before(b)
+	})
+	return ids
+}
+
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.chats)
+}
@@ -0,0 +1,34 @@
+package chats
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chats.json")
+	store, err := NewStore(path)
+	require.NoError(t, err)
+
+	now := time.Date(2023, time.May, 10, 14, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Add(3))
+	now = now.Add(time.Minute)
+	require.NoError(t, store.Add(1))
+	require.NoError(t, store.Add(2))
+	now = now.Add(time.Minute)
+	require.NoError(t, store.Add(3))
+	assert.Equal(t, []int64{3, 1, 2}, store.List(), "oldest first, seen again keeps its place")
+
+	require.NoError(t, store.Remove(1))
+	require.NoError(t, store.Remove(42))
+
+	restarted, err := NewStore(path)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 2}, restarted.List())
+	assert.Equal(t, 2, restarted.Len())
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	"telegram_service/internal/config"
+	"telegram_service/internal/ratelimit"
+	"time"
+)
+
+// Dial opens a long-lived connection to upstream. Calls that fail with
+// UNAVAILABLE are retried with backoff up to upstream.MaxAttempts times.
+func Dial(upstream config.Upstream, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    upstream.Keepalive,
+			Timeout: 20 * time.Second,
+		}),
+		grpc.WithDefaultServiceConfig(serviceConfig(upstream.MaxAttempts)),
+	}, opts...)
+
+	conn, err := grpc.Dial(upstream.Address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", upstream.Address, err)
+	}
+	return conn, nil
+}
+
+// Limit makes every call wait for a slot of sem. A call that gets none before
+// its deadline fails with RESOURCE_EXHAUSTED. Connections sharing sem share
+// the cap.
+func Limit(sem *ratelimit.Semaphore) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := sem.Acquire(ctx)
+		if err != nil {
+			return status.Errorf(codes.ResourceExhausted, "too many calls in flight: %v", err)
+		}
+		defer sem.Release()
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	})
+}
+
+func serviceConfig(maxAttempts int) string {
+	if maxAttempts < 2 {
+		return `{}`
+	}
+	return fmt.Sprintf(`{
+		"methodConfig": [{
+			"name": [{}],
+			"retryPolicy": {
+				"maxAttempts": %d,
+				"initialBackoff": "0.1s",
+				"maxBackoff": "1s",
+				"backoffMultiplier": 2,
+				"retryableStatusCodes": ["UNAVAILABLE"]
+			}
+		}]
+	}`, maxAttempts)
+}
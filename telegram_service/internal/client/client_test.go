@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+	"sync/atomic"
+	pb "telegram_service/cmd/weather/pb"
+	"telegram_service/internal/config"
+	"telegram_service/internal/ratelimit"
+	"testing"
+	"time"
+)
+
+type flakyServer struct {
+	pb.UnimplementedGetWeatherServer
+	failures int32
+	calls    int32
+	code     codes.Code
+}
+
+func (s *flakyServer) Get(_ context.Context, req *pb.Request) (*pb.Response, error) {
+	if atomic.AddInt32(&s.calls, 1) <= s.failures {
+		return nil, status.Error(s.code, "try again")
+	}
+	return &pb.Response{City: req.GetCity()}, nil
+}
+
+func serve(t *testing.T, srv pb.GetWeatherServer) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := grpc.NewServer()
+	pb.RegisterGetWeatherServer(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	return lis.Addr().String()
+}
+
+func TestDial_Retries(t *testing.T) {
+	var useCase = []struct {
+		Name        string
+		Code        codes.Code
+		Failures    int32
+		MaxAttempts int
+		Calls       int32
+		IsError     bool
+	}{
+		{Name: "Retried until success", Code: codes.Unavailable, Failures: 2, MaxAttempts: 3, Calls: 3, IsError: false},
+		{Name: "Attempts exhausted", Code: codes.Unavailable, Failures: 5, MaxAttempts: 3, Calls: 3, IsError: true},
+		{Name: "Retries disabled", Code: codes.Unavailable, Failures: 1, MaxAttempts: 1, Calls: 1, IsError: true},
+		{Name: "Not retryable", Code: codes.NotFound, Failures: 1, MaxAttempts: 3, Calls: 1, IsError: true},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			srv := &flakyServer{failures: us.Failures, code: us.Code}
+			conn, err := Dial(config.Upstream{
+				Address:     serve(t, srv),
+				MaxAttempts: us.MaxAttempts,
+				Keepalive:   5 * time.Minute,
+			})
+			require.NoError(t, err)
+			defer conn.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			res, err := pb.NewGetWeatherClient(conn).Get(ctx, &pb.Request{City: "Minsk"})
+
+			assert.Equal(t, us.Calls, atomic.LoadInt32(&srv.calls))
+			if us.IsError {
+				assert.Equal(t, us.Code, status.Code(err))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "Minsk", res.GetCity())
+			}
+		})
+	}
+}
+
+// blockingServer answers once release is closed.
+type blockingServer struct {
+	pb.UnimplementedGetWeatherServer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingServer) Get(_ context.Context, req *pb.Request) (*pb.Response, error) {
+	s.started <- struct{}{}
+	<-s.release
+	return &pb.Response{City: req.GetCity()}, nil
+}
+
+func TestLimit(t *testing.T) {
+	srv := &blockingServer{started: make(chan struct{}, 2), release: make(chan struct{})}
+	conn, err := Dial(config.Upstream{Address: serve(t, srv), MaxAttempts: 1, Keepalive: 5 * time.Minute},
+		Limit(ratelimit.NewSemaphore(1)))
+	require.NoError(t, err)
+	defer conn.Close()
+	weather := pb.NewGetWeatherClient(conn)
+
+	first := make(chan error, 1)
+	go func() {
+		_, err := weather.Get(context.Background(), &pb.Request{City: "Minsk"})
+		first <- err
+	}()
+	<-srv.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = weather.Get(ctx, &pb.Request{City: "Brest"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	close(srv.release)
+	assert.NoError(t, <-first)
+
+	_, err = weather.Get(context.Background(), &pb.Request{City: "Brest"})
+	assert.NoError(t, err, "the slot is free again")
+}
@@ -1,12 +1,181 @@
 package config
 
-import "github.com/kelseyhightower/envconfig"
+import (
+	"errors"
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"time"
+)
+
+const (
+	SessionMemory = "memory"
+	SessionFile   = "file"
+)
+
+const (
+	ModePolling = "polling"
+	ModeWebhook = "webhook"
+)
 
 type Config struct {
-	Token string `envconfig:"token"`
-	Port  string `envconfig:"port"`
+	Token     string    `envconfig:"token"`
+	Endpoint  string    `envconfig:"endpoint" default:"https://api.telegram.org/bot%s/%s"`
+	Port      string    `envconfig:"port"`
+	Mode      string    `envconfig:"mode" default:"polling"`
+	Webhook   Webhook   `envconfig:"webhook"`
+	Session   Session   `envconfig:"session"`
+	Workers   Workers   `envconfig:"workers"`
+	Weather   Upstream  `envconfig:"weather"`
+	User      Upstream  `envconfig:"user"`
+	Scheduler Scheduler `envconfig:"scheduler"`
+	Alerts    Alerts    `envconfig:"alerts"`
+	Inline    Inline    `envconfig:"inline"`
+	Prefs     Prefs     `envconfig:"prefs"`
+	Limits    Limits    `envconfig:"limits"`
+	Metrics   Metrics   `envconfig:"metrics"`
+	Admin     Admin     `envconfig:"admin"`
+	Chats     Chats     `envconfig:"chats"`
+}
+
+// Admin lists the chats allowed to run the admin commands. Broadcasts send
+// up to BroadcastRate messages per second, under Telegram's limit of 30.
+type Admin struct {
+	IDs           []int64 `envconfig:"ids"`
+	BroadcastRate float64 `envconfig:"broadcast_rate" default:"20"`
+}
+
+// Chats keeps every chat that has talked to the bot, for broadcasts.
+type Chats struct {
+	Path string `envconfig:"path" default:"data/chats.json"`
+}
+
+// Metrics serves the expvar counters at /debug/vars on Address. Empty
+// Address serves none.
+type Metrics struct {
+	Address string `envconfig:"address"`
+}
+
+// Limits protects the bot and its upstreams from floods. A chat may send Burst
+// updates at once and Rate per second on average. After LoginAttempts failed
+// logins in a row the chat, and the account tried, is locked out for Lockout,
+// doubled on every next lockout up to MaxLockout. At most Upstream calls to the upstreams run at
+// once.
+type Limits struct {
+	Rate          float64       `envconfig:"rate" default:"1"`
+	Burst         int           `envconfig:"burst" default:"5"`
+	LoginAttempts int           `envconfig:"login_attempts" default:"5"`
+	Lockout       time.Duration `envconfig:"lockout" default:"1m"`
+	MaxLockout    time.Duration `envconfig:"max_lockout" default:"1h"`
+	Upstream      int           `envconfig:"upstream" default:"32"`
+}
+
+// Prefs keeps every chat's units, language, home city and time zone.
+type Prefs struct {
+	Path string `envconfig:"path" default:"data/preferences.json"`
+}
+
+// Inline answers inline queries, which have to be enabled with BotFather's
+// /setinline. Answers are cached by query for CacheTTL.
+type Inline struct {
+	CacheTTL  time.Duration `envconfig:"cache_ttl" default:"5m"`
+	CacheSize int           `envconfig:"cache_size" default:"1000"`
+}
+
+// Alerts checks the alert rules every Interval. A rule alerts again no sooner
+// than Cooldown after its last alert.
+type Alerts struct {
+	Path     string        `envconfig:"path" default:"data/alerts.json"`
+	Interval time.Duration `envconfig:"interval" default:"10m"`
+	Cooldown time.Duration `envconfig:"cooldown" default:"6h"`
+}
+
+// Scheduler sends the daily digests. Digests missed while the bot was down are
+// sent late by up to CatchUp.
+type Scheduler struct {
+	Path     string        `envconfig:"path" default:"data/subscriptions.json"`
+	Interval time.Duration `envconfig:"interval" default:"1m"`
+	CatchUp  time.Duration `envconfig:"catch_up" default:"3h"`
+}
+
+// Upstream is a gRPC service the bot calls. Timeout bounds every call,
+// including retries of calls that failed with UNAVAILABLE.
+type Upstream struct {
+	Address     string        `envconfig:"address"`
+	Timeout     time.Duration `envconfig:"timeout" default:"5s"`
+	MaxAttempts int           `envconfig:"max_attempts" default:"3"`
+	// Keepalive is how often an idle connection is pinged. Servers reject
+	// pings more frequent than every 5 minutes by default.
+	Keepalive time.Duration `envconfig:"keepalive" default:"5m"`
+}
+
+// Workers bounds update processing. Updates of one chat are handled in order,
+// different chats in parallel. QueueDepth is how many updates of one chat may
+// wait; more are dropped.
+type Workers struct {
+	Count      int `envconfig:"count" default:"8"`
+	QueueDepth int `envconfig:"queue_depth" default:"64"`
+}
+
+// Webhook is where Telegram delivers updates in webhook mode. The bot listens
+// on Port and registers URL with Telegram on startup.
+type Webhook struct {
+	URL    string `envconfig:"url"`
+	Path   string `envconfig:"path" default:"/webhook"`
+	Secret string `envconfig:"secret"`
+}
+
+type Session struct {
+	Store string        `envconfig:"store" default:"memory"`
+	Path  string        `envconfig:"path" default:"data/sessions.json"`
+	TTL   time.Duration `envconfig:"ttl" default:"1h"`
 }
 
 func (c *Config) Process() error {
-	return envconfig.Process("telegram", c)
+	err := envconfig.Process("telegram", c)
+	if err != nil {
+		return err
+	}
+
+	if c.Session.Store != SessionMemory && c.Session.Store != SessionFile {
+		return fmt.Errorf("unknown session store %q", c.Session.Store)
+	}
+
+	if c.Inline.CacheSize < 1 {
+		return errors.New("inline cache size must be positive")
+	}
+
+	if c.Limits.Rate <= 0 || c.Limits.Burst < 1 || c.Limits.LoginAttempts < 1 || c.Limits.Upstream < 1 {
+		return errors.New("limits must be positive")
+	}
+
+	if c.Admin.BroadcastRate <= 0 {
+		return errors.New("broadcast rate must be positive")
+	}
+
+	if c.Limits.Lockout <= 0 || c.Limits.MaxLockout < c.Limits.Lockout {
+		return errors.New("lockout must be positive and not above max lockout")
+	}
+
+	if c.Workers.Count < 1 || c.Workers.QueueDepth < 1 {
+		return errors.New("workers count and queue depth must be positive")
+	}
+
+	if c.Scheduler.Interval <= 0 || c.Alerts.Interval <= 0 {
+		return errors.New("scheduler and alerts intervals must be positive")
+	}
+
+	if c.Weather.Address == "" || c.User.Address == "" {
+		return errors.New("weather and user addresses are required")
+	}
+
+	switch c.Mode {
+	case ModePolling:
+	case ModeWebhook:
+		if c.Port == "" || c.Webhook.URL == "" || c.Webhook.Secret == "" {
+			return errors.New("webhook mode needs port, webhook url and webhook secret")
+		}
+	default:
+		return fmt.Errorf("unknown mode %q", c.Mode)
+	}
+	return nil
 }
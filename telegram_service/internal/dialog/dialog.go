@@ -0,0 +1,264 @@
+package dialog
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"sync"
+	"telegram_service/internal/session"
+	"time"
+)
+
+const (
+	cancelled       = "Cancelled"
+	nothingToCancel = "There is nothing to cancel"
+)
+
+// Bot is the part of tgbotapi.BotAPI dialogs use.
+type Bot interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+}
+
+// StepFunc handles an answer of the chat. Unless it calls Next or Finish, the
+// dialog stays at the same step, waiting for a better answer.
+type StepFunc func(c *Conversation, text string) error
+
+// Dialog is a multi-step conversation. Each answer goes to the step the chat
+// is at. The chat has Timeout to answer, then the dialog ends with Expired.
+// An answer that comes too late to a Sensitive step, such as a password, is
+// deleted all the same.
+type Dialog struct {
+	Name      string
+	Steps     map[string]StepFunc
+	Sensitive map[string]bool
+	Timeout   time.Duration
+	Expired   string
+}
+
+// Conversation is a dialog at one answer of a chat.
+type Conversation struct {
+	Bot     Bot
+	Message *tgbotapi.Message
+	state   *session.Dialog
+	done    bool
+}
+
+func (c *Conversation) Get(key string) string {
+	return c.state.Data[key]
+}
+
+func (c *Conversation) Set(key, value string) {
+	if c.state.Data == nil {
+		c.state.Data = map[string]string{}
+	}
+	c.state.Data[key] = value
+}
+
+// Next moves the dialog to step.
+func (c *Conversation) Next(step string) {
+	c.state.Step = step
+}
+
+// Finish ends the dialog after the step.
+func (c *Conversation) Finish() {
+	c.done = true
+}
+
+// Reply answers the message being handled.
+func (c *Conversation) Reply(text string) error {
+	return c.ReplyWithKeyboard(text, nil)
+}
+
+// ReplyWithKeyboard replies with an inline or reply keyboard. A nil markup
+// sends no keyboard.
+func (c *Conversation) ReplyWithKeyboard(text string, markup interface{}) error {
+	m := tgbotapi.NewMessage(c.Message.Chat.ID, text)
+	m.ReplyToMessageID = c.Message.MessageID
+	if markup != nil {
+		m.ReplyMarkup = markup
+	}
+
+	_, err := c.Bot.Send(m)
+	return err
+}
+
+// forget deletes the message being handled, which holds a secret, and says
+// text without a reply to the deleted message.
+func (c *Conversation) forget(text string) error {
+	_, err := c.Bot.Request(tgbotapi.NewDeleteMessage(c.Message.Chat.ID, c.Message.MessageID))
+	if err != nil {
+		log.Printf("Failed to delete message in chat %d: %v", c.Message.Chat.ID, err)
+		return c.Reply(text)
+	}
+
+	_, err = c.Bot.Send(tgbotapi.NewMessage(c.Message.Chat.ID, text))
+	return err
+}
+
+// Manager runs the dialogs. Their state lives in the chat's session, so a
+// file session store keeps dialogs across restarts.
+type Manager struct {
+	mu      sync.Mutex
+	store   session.Store
+	dialogs map[string]*Dialog
+	now     func() time.Time
+}
+
+func NewManager(store session.Store) *Manager {
+	return &Manager{
+		store:   store,
+		dialogs: map[string]*Dialog{},
+		now:     time.Now,
+	}
+}
+
+// WithClock makes the manager tell the time with now. It is meant for tests.
+func (m *Manager) WithClock(now func() time.Time) *Manager {
+	m.now = now
+	return m
+}
+
+// Register panics when a dialog with the same name exists, like Router.Register.
+func (m *Manager) Register(d *Dialog) {
+	if _, ok := m.dialogs[d.Name]; ok {
+		panic(fmt.Sprintf("dialog %q registered twice", d.Name))
+	}
+	m.dialogs[d.Name] = d
+}
+
+// Begin puts the chat at step of the dialog, replacing any dialog it was in.
+// The caller asks the first question.
+func (m *Manager) Begin(chatID int64, name, step string, data map[string]string) error {
+	d, ok := m.dialogs[name]
+	if !ok {
+		return fmt.Errorf("unknown dialog %q", name)
+	}
+	if _, ok := d.Steps[step]; !ok {
+		return fmt.Errorf("unknown step %q of dialog %q", step, name)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.save(chatID, &session.Dialog{Name: name, Step: step, Data: data, Expires: m.now().Add(d.Timeout)})
+}
+
+// Active reports the dialog the chat is in.
+func (m *Manager) Active(chatID int64) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.load(chatID)
+	if err != nil || state == nil || !m.now().Before(state.Expires) {
+		return "", false
+	}
+	return state.Name, true
+}
+
+// Handle passes the message to the step the chat is at. It reports false when
+// the chat is in no dialog.
+func (m *Manager) Handle(bot Bot, msg *tgbotapi.Message) (bool, error) {
+	m.mu.Lock()
+	state, err := m.load(msg.Chat.ID)
+	m.mu.Unlock()
+	if err != nil || state == nil {
+		return false, err
+	}
+
+	d, ok := m.dialogs[state.Name]
+	if !ok {
+		return false, m.end(msg.Chat.ID)
+	}
+	step, ok := d.Steps[state.Step]
+	if !ok {
+		return false, m.end(msg.Chat.ID)
+	}
+
+	c := &Conversation{Bot: bot, Message: msg, state: state}
+	if !m.now().Before(state.Expires) {
+		err = m.end(msg.Chat.ID)
+		if err != nil {
+			return true, err
+		}
+		if d.Sensitive[state.Step] {
+			return true, c.forget(d.Expired)
+		}
+		return true, c.Reply(d.Expired)
+	}
+
+	err = step(c, msg.Text)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c.done {
+		return true, firstError(err, m.clear(msg.Chat.ID))
+	}
+	state.Expires = m.now().Add(d.Timeout)
+	return true, firstError(err, m.save(msg.Chat.ID, state))
+}
+
+// Cancel ends the chat's dialog on /cancel.
+func (m *Manager) Cancel(bot Bot, msg *tgbotapi.Message) error {
+	c := &Conversation{Bot: bot, Message: msg}
+	if _, ok := m.Active(msg.Chat.ID); !ok {
+		return c.Reply(nothingToCancel)
+	}
+
+	err := m.end(msg.Chat.ID)
+	if err != nil {
+		return err
+	}
+	return c.Reply(cancelled)
+}
+
+func (m *Manager) end(chatID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.clear(chatID)
+}
+
+// load returns the chat's dialog, nil when there is none. The caller holds
+// the lock.
+func (m *Manager) load(chatID int64) (*session.Dialog, error) {
+	s, ok, err := m.store.Get(chatID)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return s.Dialog, nil
+}
+
+// save keeps the rest of the session. The caller holds the lock.
+func (m *Manager) save(chatID int64, state *session.Dialog) error {
+	s, _, err := m.store.Get(chatID)
+	if err != nil {
+		return err
+	}
+	s.Dialog = state
+	return m.store.Set(chatID, s)
+}
+
+// clear drops the dialog, and the session with it when the chat is not
+// logged in. The caller holds the lock.
+func (m *Manager) clear(chatID int64) error {
+	s, ok, err := m.store.Get(chatID)
+	if err != nil || !ok || s.Dialog == nil {
+		return err
+	}
+	if !s.LoggedIn() {
+		return m.store.Delete(chatID)
+	}
+	s.Dialog = nil
+	return m.store.Set(chatID, s)
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package dialog_test
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"telegram_service/internal/dialog"
+	"telegram_service/internal/dialog/dialogtest"
+	"telegram_service/internal/session"
+	"testing"
+	"time"
+)
+
+// order asks for a pizza size and then the address.
+func order() *dialog.Dialog {
+	return &dialog.Dialog{
+		Name:    "order",
+		Timeout: time.Minute,
+		Expired: "Too slow",
+		Steps: map[string]dialog.StepFunc{
+			"size": func(c *dialog.Conversation, text string) error {
+				if text != "small" && text != "large" {
+					return c.Reply("Small or large?")
+				}
+				c.Set("size", text)
+				c.Next("address")
+				return c.Reply("Where to?")
+			},
+			"address": func(c *dialog.Conversation, text string) error {
+				c.Finish()
+				return c.Reply("A " + c.Get("size") + " pizza goes to " + text)
+			},
+		},
+	}
+}
+
+func TestManager(t *testing.T) {
+	h := dialogtest.New(t, order())
+
+	assert.False(t, h.Send("large"))
+	h.Expect()
+
+	h.Begin("order", "size", nil)
+	h.Send("huge")
+	h.Expect("Small or large?")
+	h.Send("large")
+	h.Expect("Where to?")
+	h.Send("Main street")
+	h.Expect("A large pizza goes to Main street")
+
+	_, ok := h.Active()
+	assert.False(t, ok)
+	_, ok, err := h.Sessions.Get(dialogtest.ChatID)
+	require.NoError(t, err)
+	assert.False(t, ok, "a finished dialog leaves no session behind")
+}
+
+func TestManager_Timeout(t *testing.T) {
+	h := dialogtest.New(t, order())
+	h.Begin("order", "size", nil)
+
+	h.Advance(30 * time.Second)
+	h.Send("small")
+	h.Expect("Where to?")
+
+	h.Advance(59 * time.Second)
+	_, ok := h.Active()
+	assert.True(t, ok, "every answer gives the chat another timeout")
+
+	h.Advance(time.Second)
+	assert.True(t, h.Send("Main street"))
+	h.Expect("Too slow")
+	assert.Empty(t, h.Deleted())
+	assert.False(t, h.Send("Main street"))
+}
+
+func TestManager_ExpiredSecret(t *testing.T) {
+	d := order()
+	d.Sensitive = map[string]bool{"address": true}
+	h := dialogtest.New(t, d)
+	h.Begin("order", "address", nil)
+
+	h.Advance(time.Minute)
+	assert.True(t, h.Send("Main street 1, the door code is 1234"))
+	h.Expect("Too slow")
+	assert.Equal(t, []int{1}, h.Deleted(), "a late secret is deleted")
+}
+
+func TestManager_Cancel(t *testing.T) {
+	h := dialogtest.New(t, order())
+
+	h.Send("/cancel")
+	h.Expect("There is nothing to cancel")
+
+	h.Begin("order", "size", nil)
+	h.Send("/cancel")
+	h.Expect("Cancelled")
+	assert.False(t, h.Send("small"))
+}
+
+func TestManager_KeepsSession(t *testing.T) {
+	h := dialogtest.New(t, order())
+	require.NoError(t, h.Sessions.Set(dialogtest.ChatID, session.Session{UserID: "user", Token: "token"}))
+
+	h.Begin("order", "size", nil)
+	h.Send("small")
+	h.Send("Main street")
+
+	s, ok, err := h.Sessions.Get(dialogtest.ChatID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "token", s.Token)
+	assert.Nil(t, s.Dialog)
+}
+
+func TestManager_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	first, err := session.NewFileStore(path, time.Hour)
+	require.NoError(t, err)
+	m := dialog.NewManager(first)
+	m.Register(order())
+	require.NoError(t, m.Begin(1, "order", "address", map[string]string{"size": "small"}))
+
+	second, err := session.NewFileStore(path, time.Hour)
+	require.NoError(t, err)
+	m = dialog.NewManager(second)
+	m.Register(order())
+
+	name, ok := m.Active(1)
+	assert.True(t, ok)
+	assert.Equal(t, "order", name)
+
+	msg := &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 1}, Text: "Main street"}
+	handled, err := m.Handle(&dialogtest.Bot{}, msg)
+	require.NoError(t, err)
+	assert.True(t, handled)
+	_, ok = m.Active(1)
+	assert.False(t, ok)
+}
+
+func TestManager_Register(t *testing.T) {
+	m := dialog.NewManager(session.NewMemoryStore(time.Hour))
+	m.Register(order())
+	assert.Panics(t, func() { m.Register(order()) })
+	assert.Error(t, m.Begin(1, "unknown", "size", nil))
+	assert.Error(t, m.Begin(1, "order", "unknown", nil))
+}
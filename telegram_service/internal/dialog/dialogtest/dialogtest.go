@@ -0,0 +1,141 @@
+// Package dialogtest runs dialogs without Telegram: it feeds them messages
+// and records what they send.
+package dialogtest
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"sync"
+	"telegram_service/internal/dialog"
+	"telegram_service/internal/session"
+	"testing"
+	"time"
+)
+
+// ChatID is the chat the harness talks in.
+const ChatID = 100
+
+// Bot records the messages and requests instead of sending them.
+type Bot struct {
+	mu       sync.Mutex
+	sent     []tgbotapi.Chattable
+	requests []tgbotapi.Chattable
+}
+
+func (b *Bot) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.sent = append(b.sent, c)
+	return tgbotapi.Message{MessageID: len(b.sent)}, nil
+}
+
+func (b *Bot) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.requests = append(b.requests, c)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+// Harness talks to the dialogs of a manager as one chat.
+type Harness struct {
+	t        *testing.T
+	Manager  *dialog.Manager
+	Sessions session.Store
+	Bot      *Bot
+	now      time.Time
+	nextID   int
+}
+
+// New registers the dialogs with a manager keeping state in memory.
+func New(t *testing.T, dialogs ...*dialog.Dialog) *Harness {
+	h := &Harness{
+		t:        t,
+		Sessions: session.NewMemoryStore(time.Hour),
+		Bot:      &Bot{},
+		now:      time.Date(2023, time.May, 10, 14, 0, 0, 0, time.UTC),
+	}
+	h.Manager = dialog.NewManager(h.Sessions).WithClock(func() time.Time { return h.now })
+	for _, d := range dialogs {
+		h.Manager.Register(d)
+	}
+	return h
+}
+
+// Begin starts a dialog the way a command would.
+func (h *Harness) Begin(name, step string, data map[string]string) {
+	require.NoError(h.t, h.Manager.Begin(ChatID, name, step, data))
+}
+
+// Send feeds a message from the chat, "/cancel" included, and reports
+// whether a dialog took it.
+func (h *Harness) Send(text string) bool {
+	h.nextID++
+	msg := &tgbotapi.Message{MessageID: h.nextID, Chat: &tgbotapi.Chat{ID: ChatID}, Text: text}
+
+	if text == "/cancel" {
+		require.NoError(h.t, h.Manager.Cancel(h.Bot, msg))
+		return true
+	}
+
+	handled, err := h.Manager.Handle(h.Bot, msg)
+	require.NoError(h.t, err)
+	return handled
+}
+
+// Advance moves the clock of the manager.
+func (h *Harness) Advance(d time.Duration) {
+	h.now = h.now.Add(d)
+}
+
+// Replies returns the texts of the messages sent since the last call.
+func (h *Harness) Replies() []string {
+	h.Bot.mu.Lock()
+	defer h.Bot.mu.Unlock()
+
+	var texts []string
+	for _, c := range h.Bot.sent {
+		if m, ok := c.(tgbotapi.MessageConfig); ok {
+			texts = append(texts, m.Text)
+		}
+	}
+	h.Bot.sent = nil
+	return texts
+}
+
+// Expect asserts that the replies since the last call start with prefixes,
+// one reply per prefix.
+func (h *Harness) Expect(prefixes ...string) {
+	h.t.Helper()
+
+	replies := h.Replies()
+	if !assert.Len(h.t, replies, len(prefixes), "replies: %q", replies) {
+		return
+	}
+	for i, prefix := range prefixes {
+		assert.True(h.t, strings.HasPrefix(replies[i], prefix), "reply %q does not start with %q", replies[i], prefix)
+	}
+}
+
+// Deleted returns the IDs of the messages deleted since the last call.
+func (h *Harness) Deleted() []int {
+	h.Bot.mu.Lock()
+	defer h.Bot.mu.Unlock()
+
+	var ids []int
+	for _, c := range h.Bot.requests {
+		if d, ok := c.(tgbotapi.DeleteMessageConfig); ok {
+			ids = append(ids, d.MessageID)
+		}
+	}
+	h.Bot.requests = nil
+	return ids
+}
+
+// Active reports the dialog the chat is in.
+func (h *Harness) Active() (string, bool) {
+	return h.Manager.Active(ChatID)
+}
@@ -0,0 +1,104 @@
+package prefs
+
+import (
+	"sync"
+	"telegram_service/internal/service"
+	"telegram_service/internal/storage"
+	"time"
+)
+
+// Preferences shape every weather reply of a chat. HomeCoord is where
+// HomeCity was found when it was set, so it is not looked up again.
+type Preferences struct {
+	Units     string         `json:"units"`
+	Language  string         `json:"language"`
+	HomeCity  string         `json:"home_city,omitempty"`
+	HomeCoord *service.Coord `json:"home_coord,omitempty"`
+	Timezone  string         `json:"timezone,omitempty"`
+}
+
+type Language struct {
+	Code string
+	Name string
+}
+
+// Languages are the ones offered in /settings.
+var Languages = []Language{
+	{Code: "en", Name: "English"},
+	{Code: "ru", Name: "Русский"},
+	{Code: "be", Name: "Беларуская"},
+	{Code: "de", Name: "Deutsch"},
+	{Code: "pl", Name: "Polski"},
+}
+
+func Default() Preferences {
+	return Preferences{Units: service.UnitsMetric, Language: "en"}
+}
+
+// LanguageName returns the name of the preferred language.
+func (p Preferences) LanguageName() string {
+	for _, l := range Languages {
+		if l.Code == p.Language {
+			return l.Name
+		}
+	}
+	return p.Language
+}
+
+// Location is the preferred time zone, UTC when none is set.
+func (p Preferences) Location() *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil || p.Timezone == "" {
+		return time.UTC
+	}
+	return loc
+}
+
+// Store keeps the preferences of every chat in a JSON file.
+type Store struct {
+	mu    sync.Mutex
+	prefs map[int64]Preferences
+	path  string
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		prefs: map[int64]Preferences{},
+		path:  path,
+	}
+
+	err := storage.ReadJSON(path, &s.prefs)
+	if err != nil {
+		return nil, err
+	}
+	if s.prefs == nil {
+		s.prefs = map[int64]Preferences{}
+	}
+	return s, nil
+}
+
+// Get returns the chat's preferences, or the defaults.
+func (s *Store) Get(chatID int64) Preferences {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.prefs[chatID]
+	if !ok {
+		return Default()
+	}
+	return p
+}
+
+// Update changes the chat's preferences with f and saves them.
+func (s *Store) Update(chatID int64, f func(p *Preferences)) (Preferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.prefs[chatID]
+	if !ok {
+		p = Default()
+	}
+	f(&p)
+	s.prefs[chatID] = p
+	return p, storage.WriteJSON(s.path, s.prefs)
+}
@@ -0,0 +1,41 @@
+package prefs
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"telegram_service/internal/service"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preferences.json")
+	store, err := NewStore(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, Default(), store.Get(1))
+
+	p, err := store.Update(1, func(p *Preferences) {
+		p.Units = service.UnitsImperial
+		p.HomeCity = "Minsk"
+		p.HomeCoord = &service.Coord{Lat: 53.9, Lon: 27.5667}
+		p.Timezone = "Europe/Minsk"
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "en", p.Language, "untouched fields keep their defaults")
+
+	restarted, err := NewStore(path)
+	require.NoError(t, err)
+	assert.Equal(t, p, restarted.Get(1))
+	assert.Equal(t, Default(), restarted.Get(2))
+}
+
+func TestPreferences(t *testing.T) {
+	p := Preferences{Language: "ru", Timezone: "Europe/Minsk"}
+	assert.Equal(t, "Русский", p.LanguageName())
+	assert.Equal(t, "Europe/Minsk", p.Location().String())
+
+	assert.Equal(t, time.UTC, Preferences{}.Location())
+	assert.Equal(t, time.UTC, Preferences{Timezone: "Nowhere/City"}.Location())
+}
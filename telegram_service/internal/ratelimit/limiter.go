@@ -0,0 +1,103 @@
+// Package ratelimit keeps chats from flooding the bot and its upstreams. Its
+// counters are published with expvar under "ratelimit".
+package ratelimit
+
+import (
+	"expvar"
+	"math"
+	"sync"
+	"time"
+)
+
+var metrics = expvar.NewMap("ratelimit")
+
+// Count returns the value of a counter, such as "limited" or "lockouts".
+func Count(name string) int64 {
+	v, ok := metrics.Get(name).(*expvar.Int)
+	if !ok {
+		return 0
+	}
+	return v.Value()
+}
+
+// Result is the verdict on one update of a chat.
+type Result struct {
+	Allowed bool
+	// RetryAfter is how long until the chat may send again.
+	RetryAfter time.Duration
+	// Warn is set on the first refusal after an allowed update, so the chat
+	// is told once instead of on every message.
+	Warn bool
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	warned bool
+}
+
+// Limiter is a token bucket per chat: a chat may send burst updates at once
+// and rate updates per second on average.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[int64]*bucket
+	rate    float64
+	burst   float64
+	swept   time.Time
+	now     func() time.Time
+}
+
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		buckets: map[int64]*bucket{},
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the chat's bucket.
+func (l *Limiter) Allow(chatID int64) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[chatID]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[chatID] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.warned = false
+		return Result{Allowed: true}
+	}
+
+	metrics.Add("limited", 1)
+	r := Result{
+		RetryAfter: time.Duration((1 - b.tokens) / l.rate * float64(time.Second)),
+		Warn:       !b.warned,
+	}
+	b.warned = true
+	return r
+}
+
+// sweep forgets the buckets that have refilled, once a minute. The caller
+// holds the lock.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+
+	for chatID, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, chatID)
+		}
+	}
+}
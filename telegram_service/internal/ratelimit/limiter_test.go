@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2023, time.May, 10, 14, 0, 0, 0, time.UTC)
+	l := NewLimiter(0.5, 3)
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, l.Allow(1).Allowed, "burst message %d", i)
+	}
+
+	r := l.Allow(1)
+	assert.False(t, r.Allowed)
+	assert.True(t, r.Warn, "the first refusal warns")
+	assert.Equal(t, 2*time.Second, r.RetryAfter)
+
+	r = l.Allow(1)
+	assert.False(t, r.Allowed)
+	assert.False(t, r.Warn, "later refusals are silent")
+
+	assert.True(t, l.Allow(2).Allowed, "other chats have their own bucket")
+
+	now = now.Add(2 * time.Second)
+	assert.True(t, l.Allow(1).Allowed, "a token refilled")
+	r = l.Allow(1)
+	assert.False(t, r.Allowed)
+	assert.True(t, r.Warn, "warns again after an allowed message")
+
+	now = now.Add(time.Hour)
+	l.Allow(3)
+	assert.Len(t, l.buckets, 1, "refilled buckets are forgotten")
+}
+
+func TestLockout(t *testing.T) {
+	now := time.Date(2023, time.May, 10, 14, 0, 0, 0, time.UTC)
+	l := NewLockout[int64](3, time.Minute, 3*time.Minute)
+	l.now = func() time.Time { return now }
+
+	var useCase = []struct {
+		Name    string
+		Lockout time.Duration
+	}{
+		{Name: "First lockout", Lockout: time.Minute},
+		{Name: "Doubled", Lockout: 2 * time.Minute},
+		{Name: "Capped", Lockout: 3 * time.Minute},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			for i := 1; i < l.maxFailures; i++ {
+				assert.Zero(t, l.Fail(1))
+				assert.Zero(t, l.Locked(1))
+			}
+			assert.Equal(t, us.Lockout, l.Fail(1))
+			assert.Equal(t, us.Lockout, l.Locked(1))
+			assert.Zero(t, l.Locked(2), "other chats are not locked")
+
+			now = now.Add(us.Lockout)
+			assert.Zero(t, l.Locked(1))
+		})
+	}
+
+	l.Succeed(1)
+	l.Fail(1)
+	l.Fail(1)
+	assert.Equal(t, time.Minute, l.Fail(1), "success forgives the lockouts")
+
+	now = now.Add(forgiveAfter)
+	assert.Zero(t, l.Locked(1))
+	assert.Empty(t, l.keys, "a day forgives the chat")
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type attempts struct {
+	failures int
+	lockouts int
+	until    time.Time
+	last     time.Time
+}
+
+// Lockout locks a key, such as a chat or a login, out of logging in after
+// maxFailures failed attempts in a row. The first lockout lasts base, every
+// next one twice as long, up to max. A successful login or a day without
+// failures forgives the key.
+type Lockout[K comparable] struct {
+	mu          sync.Mutex
+	keys        map[K]*attempts
+	maxFailures int
+	base        time.Duration
+	max         time.Duration
+	now         func() time.Time
+}
+
+// forgiveAfter is how long the failures of a key are remembered.
+const forgiveAfter = 24 * time.Hour
+
+func NewLockout[K comparable](maxFailures int, base, max time.Duration) *Lockout[K] {
+	return &Lockout[K]{
+		keys:        map[K]*attempts{},
+		maxFailures: maxFailures,
+		base:        base,
+		max:         max,
+		now:         time.Now,
+	}
+}
+
+// Locked returns how long the key is still locked out, zero when it may try.
+func (l *Lockout[K]) Locked(key K) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	a, ok := l.get(key)
+	if !ok {
+		return 0
+	}
+	if wait := a.until.Sub(l.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// Fail records a failed attempt. It returns the lockout it started, zero when
+// the key may still try.
+func (l *Lockout[K]) Fail(key K) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	metrics.Add("login_failures", 1)
+
+	now := l.now()
+	a, ok := l.get(key)
+	if !ok {
+		a = &attempts{}
+		l.keys[key] = a
+	}
+	a.last = now
+	a.failures++
+	if a.failures < l.maxFailures {
+		return 0
+	}
+
+	d := l.base << a.lockouts
+	if d > l.max || d <= 0 {
+		d = l.max
+	}
+	a.failures = 0
+	a.lockouts++
+	a.until = now.Add(d)
+
+	metrics.Add("lockouts", 1)
+	return d
+}
+
+// Succeed forgives the key after a successful login.
+func (l *Lockout[K]) Succeed(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.keys, key)
+}
+
+// get returns the attempts of the key, dropping them once forgiven. The
+// caller holds the lock.
+func (l *Lockout[K]) get(key K) (*attempts, bool) {
+	a, ok := l.keys[key]
+	if !ok {
+		return nil, false
+	}
+	now := l.now()
+	if now.Sub(a.last) >= forgiveAfter && !now.Before(a.until) {
+		delete(l.keys, key)
+		return nil, false
+	}
+	return a, true
+}
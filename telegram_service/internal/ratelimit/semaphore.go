@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"context"
+)
+
+// Semaphore caps the calls to the upstreams in flight at once, whichever
+// chats they are for.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+func NewSemaphore(n int) *Semaphore {
+	return &Semaphore{slots: make(chan struct{}, n)}
+}
+
+// Acquire waits for a free slot until ctx is done.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		metrics.Add("upstream_in_flight", 1)
+		return nil
+	default:
+	}
+
+	metrics.Add("upstream_waiting", 1)
+	defer metrics.Add("upstream_waiting", -1)
+
+	select {
+	case s.slots <- struct{}{}:
+		metrics.Add("upstream_in_flight", 1)
+		return nil
+	case <-ctx.Done():
+		metrics.Add("upstream_rejected", 1)
+		return ctx.Err()
+	}
+}
+
+func (s *Semaphore) Release() {
+	<-s.slots
+	metrics.Add("upstream_in_flight", -1)
+}
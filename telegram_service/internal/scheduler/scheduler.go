@@ -0,0 +1,82 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunFunc delivers the digest of a subscription.
+type RunFunc func(ctx context.Context, sub Subscription) error
+
+// Scheduler checks the subscriptions every interval and runs the due ones.
+// Runs missed while the bot was down are caught up once, when they are late
+// by no more than catchUp; older ones are skipped.
+type Scheduler struct {
+	store    *Store
+	run      RunFunc
+	interval time.Duration
+	catchUp  time.Duration
+	now      func() time.Time
+}
+
+func New(store *Store, run RunFunc, interval, catchUp time.Duration) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		run:      run,
+		interval: interval,
+		catchUp:  catchUp,
+		now:      time.Now,
+	}
+}
+
+// Start runs the schedule until ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	now := s.now()
+
+	for _, sub := range s.store.List() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		run, ok, err := sub.Due(now)
+		if err != nil {
+			log.Printf("Failed to schedule digest for chat %d: %v", sub.ChatID, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if now.Sub(run) <= s.catchUp {
+			err = s.run(ctx, sub)
+			if err != nil {
+				// Retried on the next tick until it is too late.
+				log.Printf("Failed to send digest to chat %d: %v", sub.ChatID, err)
+				continue
+			}
+		} else {
+			log.Printf("Skipped digest for chat %d due at %s", sub.ChatID, run)
+		}
+
+		err = s.store.MarkRun(sub.ChatID, run)
+		if err != nil {
+			log.Printf("Failed to save digest run for chat %d: %v", sub.ChatID, err)
+		}
+	}
+}
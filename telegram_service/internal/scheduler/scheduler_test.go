@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Tick(t *testing.T) {
+	created := time.Date(2023, time.May, 10, 6, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "subscriptions.json")
+
+	store, err := NewStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Set(Subscription{ChatID: 1, City: "Minsk", Hour: 7, Minute: 30, Timezone: "UTC", Created: created}))
+	require.NoError(t, store.Set(Subscription{ChatID: 2, City: "Brest", Hour: 9, Minute: 0, Timezone: "UTC", Created: created}))
+
+	var sent []int64
+	fail := false
+	s := New(store, func(ctx context.Context, sub Subscription) error {
+		if fail {
+			return errors.New("weather is down")
+		}
+		sent = append(sent, sub.ChatID)
+		return nil
+	}, time.Minute, time.Hour)
+
+	s.now = func() time.Time { return created.Add(time.Hour) }
+	s.tick(context.Background())
+	assert.Empty(t, sent)
+
+	s.now = func() time.Time { return time.Date(2023, time.May, 10, 7, 31, 0, 0, time.UTC) }
+	s.tick(context.Background())
+	s.tick(context.Background())
+	assert.Equal(t, []int64{1}, sent, "runs once")
+
+	fail = true
+	s.now = func() time.Time { return time.Date(2023, time.May, 10, 9, 1, 0, 0, time.UTC) }
+	s.tick(context.Background())
+	fail = false
+	s.tick(context.Background())
+	assert.Equal(t, []int64{1, 2}, sent, "failed runs are retried")
+
+	// The bot was down for a day: the run late by more than catchUp is
+	// skipped, the recent one is caught up.
+	sent = nil
+	s.now = func() time.Time { return time.Date(2023, time.May, 11, 9, 30, 0, 0, time.UTC) }
+	s.tick(context.Background())
+	assert.Equal(t, []int64{2}, sent)
+
+	restarted, err := NewStore(path)
+	require.NoError(t, err)
+	sub, ok := restarted.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2023, time.May, 11, 7, 30, 0, 0, time.UTC), sub.LastRun.UTC())
+}
+
+func TestStore_MarkRunAfterResubscribe(t *testing.T) {
+	store, err := NewStore(filepath.Join(t.TempDir(), "subscriptions.json"))
+	require.NoError(t, err)
+
+	now := time.Date(2023, time.May, 10, 8, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Set(Subscription{ChatID: 1, Hour: 7, Minute: 30, Timezone: "UTC", Created: now}))
+	require.NoError(t, store.MarkRun(1, now.Add(-30*time.Minute)))
+
+	sub, _ := store.Get(1)
+	assert.True(t, sub.LastRun.IsZero())
+
+	ok, err := store.Delete(1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, _ = store.Delete(1)
+	assert.False(t, ok)
+}
@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"sort"
+	"sync"
+	"telegram_service/internal/storage"
+	"time"
+)
+
+// Store keeps one subscription per chat in a JSON file.
+type Store struct {
+	mu   sync.Mutex
+	subs map[int64]Subscription
+	path string
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		subs: map[int64]Subscription{},
+		path: path,
+	}
+
+	err := storage.ReadJSON(path, &s.subs)
+	if err != nil {
+		return nil, err
+	}
+	if s.subs == nil {
+		s.subs = map[int64]Subscription{}
+	}
+	return s, nil
+}
+
+func (s *Store) Get(chatID int64) (Subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[chatID]
+	return sub, ok
+}
+
+// List returns the subscriptions ordered by chat.
+func (s *Store) List() []Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]Subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		res = append(res, sub)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ChatID < res[j].ChatID })
+	return res
+}
+
+// Set replaces the subscription of the chat.
+func (s *Store) Set(sub Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.subs[sub.ChatID] = sub
+	return storage.WriteJSON(s.path, s.subs)
+}
+
+// Delete reports whether the chat had a subscription.
+func (s *Store) Delete(chatID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[chatID]; !ok {
+		return false, nil
+	}
+	delete(s.subs, chatID)
+	return true, storage.WriteJSON(s.path, s.subs)
+}
+
+// MarkRun records a run of the chat's subscription. Runs scheduled before the
+// subscription was last replaced are ignored.
+func (s *Store) MarkRun(chatID int64, run time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.subs[chatID]
+	if !ok || run.Before(sub.Created) || !run.After(sub.LastRun) {
+		return nil
+	}
+	sub.LastRun = run
+	s.subs[chatID] = sub
+	return storage.WriteJSON(s.path, s.subs)
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// Subscription asks for a digest every day at Hour:Minute local time in
+// Timezone.
+type Subscription struct {
+	ChatID   int64     `json:"chat_id"`
+	City     string    `json:"city"`
+	Hour     int       `json:"hour"`
+	Minute   int       `json:"minute"`
+	Timezone string    `json:"timezone"`
+	Created  time.Time `json:"created"`
+	LastRun  time.Time `json:"last_run,omitempty"`
+}
+
+// ParseClock parses a time of day like "07:30".
+func ParseClock(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%q is not a time like 07:30", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+func (s Subscription) Clock() string {
+	return fmt.Sprintf("%02d:%02d", s.Hour, s.Minute)
+}
+
+// Next returns the first run strictly after after. Runs are found by calendar
+// day in the subscription's time zone, so they stay at the same wall clock
+// time across DST changes. A time skipped by a DST change runs at the
+// moment that replaces it.
+func (s Subscription) Next(after time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load time zone %q: %w", s.Timezone, err)
+	}
+
+	local := after.In(loc)
+	run := time.Date(local.Year(), local.Month(), local.Day(), s.Hour, s.Minute, 0, 0, loc)
+	if !run.After(after) {
+		run = time.Date(local.Year(), local.Month(), local.Day()+1, s.Hour, s.Minute, 0, 0, loc)
+	}
+	return run, nil
+}
+
+// Due returns the latest run that should have happened by now, if any.
+func (s Subscription) Due(now time.Time) (time.Time, bool, error) {
+	last := s.LastRun
+	if last.IsZero() {
+		last = s.Created
+	}
+
+	run, err := s.Next(last)
+	if err != nil || run.After(now) {
+		return time.Time{}, false, err
+	}
+
+	for {
+		next, err := s.Next(run)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if next.After(now) {
+			return run, true, nil
+		}
+		run = next
+	}
+}
@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func TestSubscription_Next(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+
+	var useCase = []struct {
+		Name   string
+		Hour   int
+		Minute int
+		After  time.Time
+		Next   time.Time
+	}{
+		{
+			Name: "Later today", Hour: 7, Minute: 30,
+			After: time.Date(2023, time.May, 10, 6, 0, 0, 0, berlin),
+			Next:  time.Date(2023, time.May, 10, 7, 30, 0, 0, berlin),
+		},
+		{
+			Name: "Tomorrow", Hour: 7, Minute: 30,
+			After: time.Date(2023, time.May, 10, 7, 30, 0, 0, berlin),
+			Next:  time.Date(2023, time.May, 11, 7, 30, 0, 0, berlin),
+		},
+		{
+			Name: "After UTC midnight", Hour: 7, Minute: 30,
+			After: time.Date(2023, time.May, 10, 23, 0, 0, 0, time.UTC),
+			Next:  time.Date(2023, time.May, 11, 7, 30, 0, 0, berlin),
+		},
+		{
+			Name: "Across spring forward", Hour: 7, Minute: 30,
+			After: time.Date(2023, time.March, 25, 7, 30, 0, 0, berlin),
+			Next:  time.Date(2023, time.March, 26, 7, 30, 0, 0, berlin),
+		},
+		{
+			Name: "Across fall back", Hour: 7, Minute: 30,
+			After: time.Date(2023, time.October, 28, 7, 30, 0, 0, berlin),
+			Next:  time.Date(2023, time.October, 29, 7, 30, 0, 0, berlin),
+		},
+		{
+			Name: "Skipped hour", Hour: 2, Minute: 30,
+			After: time.Date(2023, time.March, 25, 12, 0, 0, 0, berlin),
+			Next:  time.Date(2023, time.March, 26, 3, 30, 0, 0, berlin),
+		},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			s := Subscription{Hour: us.Hour, Minute: us.Minute, Timezone: "Europe/Berlin"}
+			next, err := s.Next(us.After)
+			assert.NoError(t, err)
+			assert.True(t, us.Next.Equal(next), "want %s, got %s", us.Next, next)
+		})
+	}
+
+	_, err := Subscription{Timezone: "Nowhere/City"}.Next(time.Now())
+	assert.Error(t, err)
+}
+
+func TestSubscription_NextFallBackRunsOnce(t *testing.T) {
+	berlin := mustLoad(t, "Europe/Berlin")
+	s := Subscription{Hour: 2, Minute: 30, Timezone: "Europe/Berlin"}
+
+	first, err := s.Next(time.Date(2023, time.October, 28, 12, 0, 0, 0, berlin))
+	require.NoError(t, err)
+	second, err := s.Next(first)
+	require.NoError(t, err)
+
+	assert.Equal(t, 29, first.In(berlin).Day())
+	assert.Equal(t, 30, second.In(berlin).Day(), "the repeated hour does not run twice")
+}
+
+func TestSubscription_Due(t *testing.T) {
+	created := time.Date(2023, time.May, 10, 6, 0, 0, 0, time.UTC)
+	s := Subscription{Hour: 7, Minute: 30, Timezone: "UTC", Created: created}
+
+	_, ok, err := s.Due(created.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	run, ok, _ := s.Due(created.Add(2 * time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2023, time.May, 10, 7, 30, 0, 0, time.UTC), run)
+
+	run, ok, _ = s.Due(created.Add(3 * 24 * time.Hour))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2023, time.May, 12, 7, 30, 0, 0, time.UTC), run, "missed days collapse into the latest run")
+
+	s.LastRun = run
+	_, ok, _ = s.Due(run.Add(time.Hour))
+	assert.False(t, ok)
+}
+
+func TestParseClock(t *testing.T) {
+	h, m, err := ParseClock("07:30")
+	assert.NoError(t, err)
+	assert.Equal(t, 7, h)
+	assert.Equal(t, 30, m)
+
+	for _, s := range []string{"7.30", "25:00", "07:60", ""} {
+		_, _, err = ParseClock(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"telegram_service/internal/ratelimit"
+	"time"
+)
+
+// isAdmin reports whether the chat is in the configured admin list.
+func (t *Telegram) isAdmin(chatID int64) bool {
+	for _, id := range t.cfg.Admin.IDs {
+		if id == chatID {
+			return true
+		}
+	}
+	return false
+}
+
+// record counts a handled message and remembers its chat for broadcasts.
+// Unknown commands are the user's mistake, not failures.
+func (t *Telegram) record(chatID int64, err error) {
+	var unknown ErrUnknownCommand
+	t.stats.Record(err != nil && !errors.As(err, &unknown))
+
+	err = t.chats.Add(chatID)
+	if err != nil {
+		log.Printf("Failed to remember chat %d: %v", chatID, err)
+	}
+}
+
+func (t *Telegram) statsCommand(msg *tgbotapi.Message, _ string) error {
+	sessions, err := t.authService.ActiveSessions()
+	if err != nil {
+		return err
+	}
+
+	messages, failed := t.stats.LastHour()
+	rate := 0.0
+	if messages > 0 {
+		rate = float64(failed) / float64(messages) * 100
+	}
+
+	return t.reply(msg, fmt.Sprintf("Uptime: %s\nActive sessions: %d\nKnown chats: %d\n"+
+		"Last hour: %d messages, %d errors (%.1f%%)\nRate limited: %d, login lockouts: %d",
+		t.stats.Uptime().Round(time.Second), sessions, t.chats.Len(),
+		messages, failed, rate, ratelimit.Count("limited"), ratelimit.Count("lockouts")))
+}
+
+// broadcast sends the text to every known chat in the background, throttled
+// to the configured rate, and reports to the admin when done or when the bot
+// stops first. Chats that blocked the bot are forgotten.
+func (t *Telegram) broadcast(msg *tgbotapi.Message, text string) error {
+	if text == "" {
+		return t.reply(msg, "Write the message to send\nFor example: /broadcast The bot restarts at 22:00 UTC")
+	}
+	if !atomic.CompareAndSwapInt32(&t.broadcasting, 0, 1) {
+		return t.reply(msg, "A broadcast is already running, wait for it to finish")
+	}
+
+	ids := t.chats.List()
+	t.background.Add(1)
+	go func() {
+		defer t.background.Done()
+		defer atomic.StoreInt32(&t.broadcasting, 0)
+
+		sent, failed := t.sendAll(t.ctx, ids, text)
+		report := fmt.Sprintf("Broadcast done: %d sent, %d failed", sent, failed)
+		if skipped := len(ids) - sent - failed; skipped > 0 {
+			report = fmt.Sprintf("Broadcast stopped by shutdown: %d sent, %d failed, %d not reached",
+				sent, failed, skipped)
+		}
+		err := t.reply(msg, report)
+		if err != nil {
+			log.Printf("Failed to send reply: %v", err)
+		}
+	}()
+	return t.reply(msg, fmt.Sprintf("Broadcasting to %d chats", len(ids)))
+}
+
+// sendAll sends the text to ids until ctx is done.
+func (t *Telegram) sendAll(ctx context.Context, ids []int64, text string) (sent, failed int) {
+	tick := time.NewTicker(time.Duration(float64(time.Second) / t.cfg.Admin.BroadcastRate))
+	defer tick.Stop()
+
+	for _, id := range ids {
+		select {
+		case <-ctx.Done():
+			return sent, failed
+		case <-tick.C:
+		}
+		err := t.sendThrottled(tgbotapi.NewMessage(id, text))
+		if err == nil {
+			sent++
+			continue
+		}
+
+		failed++
+		log.Printf("Failed to broadcast to chat %d: %v", id, err)
+		var apiErr *tgbotapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusForbidden {
+			err = t.chats.Remove(id)
+			if err != nil {
+				log.Printf("Failed to forget chat %d: %v", id, err)
+			}
+		}
+	}
+	return sent, failed
+}
+
+// sendThrottled sends once more after the wait Telegram asks for when it
+// throttles the bot.
+func (t *Telegram) sendThrottled(c tgbotapi.Chattable) error {
+	_, err := t.bot.Send(c)
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		time.Sleep(time.Duration(apiErr.RetryAfter) * time.Second)
+		_, err = t.bot.Send(c)
+	}
+	return err
+}
+
+// kick ends a chat's session, so it has to log in again.
+func (t *Telegram) kick(msg *tgbotapi.Message, args string) error {
+	chatID, err := strconv.ParseInt(args, 10, 64)
+	if err != nil {
+		return t.reply(msg, "Write the chat ID\nFor example: /kick 123456789")
+	}
+
+	if !t.authService.Logout(chatID) {
+		return t.reply(msg, fmt.Sprintf("Chat %d is not logged in", chatID))
+	}
+
+	_, err = t.bot.Send(tgbotapi.NewMessage(chatID, "An administrator ended your session. Send /login to log in again"))
+	if err != nil {
+		log.Printf("Failed to tell chat %d about the kick: %v", chatID, err)
+	}
+	return t.reply(msg, fmt.Sprintf("Chat %d is logged out", chatID))
+}
+
+// health checks the upstreams and Telegram itself.
+func (t *Telegram) health(msg *tgbotapi.Message, _ string) error {
+	var b strings.Builder
+	for _, h := range t.healthService.Check(context.Background()) {
+		switch {
+		case h.Err != nil:
+			fmt.Fprintf(&b, "❌ %s: %v\n", h.Name, h.Err)
+		case h.Status != grpc_health_v1.HealthCheckResponse_SERVING.String():
+			fmt.Fprintf(&b, "❌ %s: %s\n", h.Name, h.Status)
+		default:
+			fmt.Fprintf(&b, "✅ %s: %s in %s\n", h.Name, h.Status, h.Latency.Round(time.Millisecond))
+		}
+	}
+
+	start := time.Now()
+	_, err := t.bot.GetMe()
+	if err != nil {
+		fmt.Fprintf(&b, "❌ telegram: %v", err)
+	} else {
+		fmt.Fprintf(&b, "✅ telegram: OK in %s", time.Since(start).Round(time.Millisecond))
+	}
+	return t.reply(msg, b.String())
+}
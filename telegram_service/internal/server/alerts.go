@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strconv"
+	"strings"
+	"telegram_service/internal/alert"
+	"telegram_service/internal/service"
+)
+
+const alertUsage = "For example: /alert frost Minsk, /alert wind Minsk 20 or /alert rain Minsk 5"
+
+// addAlert registers "<kind> <city> [threshold]".
+func (t *Telegram) addAlert(msg *tgbotapi.Message, args string) error {
+	kind, city, threshold, err := parseAlertArgs(args, true)
+	if err != nil {
+		return t.reply(msg, fmt.Sprintf("Incorrect input: %s\n%s", err, alertUsage))
+	}
+
+	ctx, err := t.authService.Context(context.Background(), msg.Chat.ID)
+	if err != nil {
+		return t.sessionError(msg, err)
+	}
+
+	tz, err := t.tgService.Timezone(ctx, city)
+	if err != nil {
+		return t.reply(msg, "Incorrect input: unknown city "+city)
+	}
+
+	r := alert.Rule{ChatID: msg.Chat.ID, City: city, Kind: kind, Threshold: threshold, Timezone: tz}
+	err = t.alerts.Add(r)
+	if err != nil {
+		return err
+	}
+	return t.reply(msg, fmt.Sprintf("I will warn you about %s", r))
+}
+
+// removeAlert removes "<kind> <city>".
+func (t *Telegram) removeAlert(msg *tgbotapi.Message, args string) error {
+	kind, city, _, err := parseAlertArgs(args, false)
+	if err != nil {
+		return t.reply(msg, fmt.Sprintf("Incorrect input: %s\nFor example: /unalert frost Minsk", err))
+	}
+
+	ok, err := t.alerts.Remove(msg.Chat.ID, kind, city)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return t.reply(msg, fmt.Sprintf("You have no %s alert for %s", kind, city))
+	}
+	return t.reply(msg, fmt.Sprintf("Removed the %s alert for %s", kind, city))
+}
+
+func (t *Telegram) listAlerts(msg *tgbotapi.Message, _ string) error {
+	rules := t.alerts.Rules(msg.Chat.ID)
+	if len(rules) == 0 {
+		return t.reply(msg, "You have no alerts.\n"+alertUsage)
+	}
+
+	lines := []string{"Your alerts:"}
+	for _, r := range rules {
+		lines = append(lines, "• "+r.String())
+	}
+	if q, ok := t.alerts.Quiet(msg.Chat.ID); ok {
+		lines = append(lines, "Quiet hours: "+q.String())
+	}
+	return t.reply(msg, strings.Join(lines, "\n"))
+}
+
+// quiet sets quiet hours like "22:00-07:00", or removes them with "off".
+func (t *Telegram) quiet(msg *tgbotapi.Message, args string) error {
+	if args == "" {
+		q, ok := t.alerts.Quiet(msg.Chat.ID)
+		if !ok {
+			return t.reply(msg, "You have no quiet hours.\nFor example: /quiet 22:00-07:00")
+		}
+		return t.reply(msg, fmt.Sprintf("Quiet hours: %s. Send /quiet off to remove them", q))
+	}
+
+	if strings.EqualFold(args, "off") {
+		err := t.alerts.SetQuiet(msg.Chat.ID, nil)
+		if err != nil {
+			return err
+		}
+		return t.reply(msg, "Quiet hours removed")
+	}
+
+	q, err := alert.ParseQuiet(args)
+	if err != nil {
+		return t.reply(msg, fmt.Sprintf("Incorrect input: %s\nFor example: /quiet 22:00-07:00", err))
+	}
+	err = t.alerts.SetQuiet(msg.Chat.ID, &q)
+	if err != nil {
+		return err
+	}
+	return t.reply(msg, fmt.Sprintf("No alerts between %s, local time of the city", q))
+}
+
+func (t *Telegram) sendAlert(_ context.Context, r alert.Rule, c service.Conditions) error {
+	_, err := t.bot.Send(tgbotapi.NewMessage(r.ChatID, r.Message(c)))
+	return err
+}
+
+// parseAlertArgs splits "<kind> <city> [threshold]". Without a threshold the
+// kind's default is used.
+func parseAlertArgs(args string, withThreshold bool) (alert.Kind, string, float64, error) {
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		return "", "", 0, fmt.Errorf("write alert and city please")
+	}
+
+	kind, err := alert.ParseKind(fields[0])
+	if err != nil {
+		return "", "", 0, err
+	}
+	fields = fields[1:]
+
+	threshold := alert.Kinds[kind]
+	if withThreshold && len(fields) > 1 {
+		n, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err == nil {
+			threshold = n
+			fields = fields[:len(fields)-1]
+		}
+	}
+	return kind, strings.Join(fields, " "), threshold, nil
+}
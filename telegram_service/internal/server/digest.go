@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"strings"
+	"telegram_service/internal/dialog"
+	"telegram_service/internal/scheduler"
+	"telegram_service/internal/service"
+	"time"
+)
+
+const dailyUsage = "For example: /daily 07:30 Minsk"
+
+const (
+	dialogDaily  = "daily"
+	stepTime     = "time"
+	stepCity     = "city"
+	dailyTimeout = 5 * time.Minute
+)
+
+const (
+	askDailyTime   = "At what local time should I send the weather? For example: 07:30"
+	askDailyCity   = "Which city? For example: Minsk"
+	dailyCancelled = "Daily digest setup timed out. Send /daily to start again"
+)
+
+var errUnknownCity = errors.New("unknown city")
+
+// daily subscribes the chat to a digest at a local time of the city, or shows
+// the current subscription. Without arguments and a subscription it asks for
+// the time and the city.
+func (t *Telegram) daily(msg *tgbotapi.Message, args string) error {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		sub, ok := t.subscriptions.Get(msg.Chat.ID)
+		if !ok {
+			err := t.dialogs.Begin(msg.Chat.ID, dialogDaily, stepTime, nil)
+			if err != nil {
+				return err
+			}
+			return t.reply(msg, "You have no daily digest yet. "+askDailyTime)
+		}
+		return t.reply(msg, fmt.Sprintf("You get the weather for %s every day at %s (%s).\nUse /unsubscribe to stop",
+			sub.City, sub.Clock(), sub.Timezone))
+	}
+	// Without a city the digest is for the home city.
+	p := t.prefs.Get(msg.Chat.ID)
+	city, tz := p.HomeCity, p.Timezone
+	if len(fields) > 1 {
+		city, tz = strings.Join(fields[1:], " "), ""
+	}
+	if city == "" {
+		return t.reply(msg, "Write time and city please\n"+dailyUsage)
+	}
+
+	hour, minute, err := scheduler.ParseClock(fields[0])
+	if err != nil {
+		return t.reply(msg, fmt.Sprintf("Incorrect input: %s\n%s", err, dailyUsage))
+	}
+
+	sub, err := t.subscribe(msg.Chat.ID, hour, minute, city, tz)
+	if errors.Is(err, errUnknownCity) {
+		return t.reply(msg, "Incorrect input: unknown city "+city)
+	}
+	if err != nil {
+		return t.sessionError(msg, err)
+	}
+	return t.reply(msg, subscribed(sub))
+}
+
+func (t *Telegram) dailyDialog() *dialog.Dialog {
+	return &dialog.Dialog{
+		Name:    dialogDaily,
+		Timeout: dailyTimeout,
+		Expired: dailyCancelled,
+		Steps: map[string]dialog.StepFunc{
+			stepTime: t.askedDailyTime,
+			stepCity: t.askedDailyCity,
+		},
+	}
+}
+
+func (t *Telegram) askedDailyTime(c *dialog.Conversation, text string) error {
+	clock := strings.TrimSpace(text)
+	_, _, err := scheduler.ParseClock(clock)
+	if err != nil {
+		return c.Reply(fmt.Sprintf("Incorrect input: %s\n%s", err, askDailyTime))
+	}
+
+	c.Set(stepTime, clock)
+	c.Next(stepCity)
+	return c.Reply(t.askDailyCity(c.Message.Chat.ID))
+}
+
+// askedDailyCity subscribes the chat. "home" stands for the home city.
+func (t *Telegram) askedDailyCity(c *dialog.Conversation, text string) error {
+	chatID := c.Message.Chat.ID
+	city, tz := strings.TrimSpace(text), ""
+	if city == "" {
+		return c.Reply(t.askDailyCity(chatID))
+	}
+	if p := t.prefs.Get(chatID); strings.EqualFold(city, "home") && p.HomeCity != "" {
+		city, tz = p.HomeCity, p.Timezone
+	}
+
+	hour, minute, err := scheduler.ParseClock(c.Get(stepTime))
+	if err != nil {
+		return err
+	}
+
+	sub, err := t.subscribe(chatID, hour, minute, city, tz)
+	if errors.Is(err, errUnknownCity) {
+		return c.Reply("Incorrect input: unknown city " + city + "\n" + t.askDailyCity(chatID))
+	}
+	c.Finish()
+	if errors.Is(err, service.ErrNotLoggedIn) {
+		return c.Reply("Your session has expired.\n" + loginRequired)
+	}
+	if err != nil {
+		return err
+	}
+	return c.Reply(subscribed(sub))
+}
+
+func (t *Telegram) askDailyCity(chatID int64) string {
+	home := t.prefs.Get(chatID).HomeCity
+	if home == "" {
+		return askDailyCity
+	}
+	return fmt.Sprintf("%s, or send \"home\" for %s", askDailyCity, home)
+}
+
+// subscribe looks up the time zone of the city unless tz is given.
+func (t *Telegram) subscribe(chatID int64, hour, minute int, city, tz string) (scheduler.Subscription, error) {
+	if tz == "" {
+		ctx, err := t.authService.Context(context.Background(), chatID)
+		if err != nil {
+			return scheduler.Subscription{}, err
+		}
+
+		tz, err = t.tgService.Timezone(ctx, city)
+		if err != nil {
+			return scheduler.Subscription{}, fmt.Errorf("%w %s: %v", errUnknownCity, city, err)
+		}
+	}
+
+	sub := scheduler.Subscription{
+		ChatID:   chatID,
+		City:     city,
+		Hour:     hour,
+		Minute:   minute,
+		Timezone: tz,
+		Created:  time.Now(),
+	}
+	return sub, t.subscriptions.Set(sub)
+}
+
+func subscribed(sub scheduler.Subscription) string {
+	return fmt.Sprintf("Done! You will get the weather for %s every day at %s (%s)",
+		sub.City, sub.Clock(), sub.Timezone)
+}
+
+func (t *Telegram) unsubscribe(msg *tgbotapi.Message, _ string) error {
+	ok, err := t.subscriptions.Delete(msg.Chat.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return t.reply(msg, "You have no daily digest")
+	}
+	return t.reply(msg, "You will no longer get the daily digest")
+}
+
+// sendDigest is run by the scheduler. Digests go out even when the chat's
+// session has expired, without a token.
+func (t *Telegram) sendDigest(ctx context.Context, sub scheduler.Subscription) error {
+	authCtx, err := t.authService.Context(ctx, sub.ChatID)
+	if err == nil {
+		ctx = authCtx
+	}
+
+	q := t.query(sub.ChatID, sub.City)
+	current, err := t.tgService.GetWeather(ctx, q)
+	if err != nil {
+		return err
+	}
+	forecast, err := t.tgService.GetForecast(ctx, q, 1)
+	if err != nil {
+		log.Printf("Failed to get digest forecast for %s: %v", sub.City, err)
+	}
+
+	text := "Good morning! Your daily weather:\n\n" + current
+	if forecast != "" {
+		text += "\n\nToday:\n" + forecast
+	}
+
+	m := tgbotapi.NewMessage(sub.ChatID, text)
+	m.ReplyMarkup = weatherKeyboard(q)
+	_, err = t.bot.Send(m)
+	return err
+}
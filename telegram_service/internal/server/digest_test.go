@@ -0,0 +1,45 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"telegram_service/internal/dialog/dialogtest"
+	"telegram_service/internal/prefs"
+	"telegram_service/internal/scheduler"
+	"testing"
+)
+
+func TestDailyDialog(t *testing.T) {
+	dir := t.TempDir()
+	preferences, err := prefs.NewStore(filepath.Join(dir, "preferences.json"))
+	require.NoError(t, err)
+	subscriptions, err := scheduler.NewStore(filepath.Join(dir, "subscriptions.json"))
+	require.NoError(t, err)
+
+	_, err = preferences.Update(dialogtest.ChatID, func(p *prefs.Preferences) {
+		p.HomeCity = "Minsk"
+		p.Timezone = "Europe/Minsk"
+	})
+	require.NoError(t, err)
+
+	tg := &Telegram{prefs: preferences, subscriptions: subscriptions}
+	h := dialogtest.New(t, tg.dailyDialog())
+	h.Begin(dialogDaily, stepTime, nil)
+
+	h.Send("25:00")
+	h.Expect("Incorrect input")
+	h.Send("07:30")
+	h.Expect(askDailyCity + `, or send "home" for Minsk`)
+	h.Send("home")
+	h.Expect("Done! You will get the weather for Minsk every day at 07:30 (Europe/Minsk)")
+
+	_, ok := h.Active()
+	assert.False(t, ok)
+
+	sub, ok := subscriptions.Get(dialogtest.ChatID)
+	assert.True(t, ok)
+	assert.Equal(t, "Minsk", sub.City)
+	assert.Equal(t, 7, sub.Hour)
+	assert.Equal(t, 30, sub.Minute)
+}
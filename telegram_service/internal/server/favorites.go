@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"telegram_service/internal/service"
+)
+
+const favUsage = "For example: /fav add Minsk, /fav remove Minsk or /fav list"
+
+// favorites handles "/fav [list|add <city>|remove <city>]". The favorites are
+// kept in the user's profile, so they follow the account, not the chat.
+func (t *Telegram) favorites(msg *tgbotapi.Message, args string) error {
+	sub, city, _ := strings.Cut(args, " ")
+	city = strings.TrimSpace(city)
+
+	ctx, err := t.authService.Context(context.Background(), msg.Chat.ID)
+	if err != nil {
+		return t.sessionError(msg, err)
+	}
+
+	switch strings.ToLower(sub) {
+	case "", "list":
+		return t.favoritesSummary(ctx, msg)
+	case "add":
+		if city == "" {
+			return t.reply(msg, "Write city please\n"+favUsage)
+		}
+		cities, err := t.favoriteService.Add(ctx, city)
+		if status.Code(err) == codes.InvalidArgument {
+			return t.reply(msg, "Incorrect input: "+status.Convert(err).Message())
+		}
+		if err != nil {
+			return err
+		}
+		return t.reply(msg, fmt.Sprintf("Added %s. Your favorites: %s", city, strings.Join(cities, ", ")))
+	case "remove":
+		if city == "" {
+			return t.reply(msg, "Write city please\n"+favUsage)
+		}
+		cities, err := t.favoriteService.Remove(ctx, city)
+		if status.Code(err) == codes.NotFound {
+			return t.reply(msg, city+" is not among your favorites")
+		}
+		if err != nil {
+			return err
+		}
+		if len(cities) == 0 {
+			return t.reply(msg, fmt.Sprintf("Removed %s. You have no favorites left", city))
+		}
+		return t.reply(msg, fmt.Sprintf("Removed %s. Your favorites: %s", city, strings.Join(cities, ", ")))
+	}
+	return t.reply(msg, "Incorrect input\n"+favUsage)
+}
+
+// favoritesSummary sends the weather in every favorite city in one message.
+func (t *Telegram) favoritesSummary(ctx context.Context, msg *tgbotapi.Message) error {
+	cities, err := t.favoriteService.List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(cities) == 0 {
+		return t.reply(msg, "You have no favorites.\n"+favUsage)
+	}
+
+	q := t.query(msg.Chat.ID, "")
+	qs := make([]service.Query, len(cities))
+	for i, city := range cities {
+		qs[i] = q
+		qs[i].City = city
+	}
+
+	reports := t.tgService.GetWeatherAll(ctx, qs)
+	return t.replyWithKeyboard(msg, favoritesText(cities, reports), citiesKeyboard(cities, q.Units))
+}
+
+func favoritesText(cities, reports []string) string {
+	parts := make([]string, len(cities))
+	for i, city := range cities {
+		parts[i] = reports[i]
+		if parts[i] == "" {
+			parts[i] = city + ": weather is not available"
+		}
+	}
+	return "Your favorites:\n\n" + strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,11 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFavoritesText(t *testing.T) {
+	text := favoritesText([]string{"Minsk", "Atlantis"}, []string{"Minsk: 5°C, clear sky", ""})
+	assert.Equal(t, "Your favorites:\n\nMinsk: 5°C, clear sky\n\nAtlantis: weather is not available", text)
+}
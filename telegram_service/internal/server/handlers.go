@@ -0,0 +1,284 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strconv"
+	"strings"
+	"telegram_service/internal/service"
+)
+
+const (
+	defaultForecastDays = 3
+	maxForecastDays     = 8
+)
+
+const (
+	loginSuccess = "You are successfully authorized. \nSelect the city where you want to know the weather. " +
+		"\nFor example: Minsk"
+	loginRequired = "First of all, you should log in.\nWrite correct login and password, please" +
+		"\n For example: /login name password, or just /login to be asked step by step" +
+		"\nNo account yet? Send /register to create one"
+)
+
+func (t *Telegram) newRouter() *Router {
+	r := NewRouter(t.authService.CheckAuth, t.needLogin)
+
+	r.Register(Command{Name: "start", Usage: "/start", Description: "Start talking to the bot", Handler: t.start})
+	r.Register(Command{Name: "help", Usage: "/help", Description: "Show available commands", Handler: t.help})
+	r.Register(Command{Name: "weather", Usage: "/weather [city]", Description: "Current weather, in your home city by default",
+		RequiresAuth: true, Handler: t.weather})
+	r.Register(Command{Name: "forecast", Usage: "/forecast [city] [days]", Description: "Daily forecast, 3 days by default",
+		RequiresAuth: true, Handler: t.forecast})
+	r.Register(Command{Name: "login", Usage: "/login [login] [password]", Description: "Log in to your account",
+		PrivateOnly: true, Handler: t.login})
+	r.Register(Command{Name: "register", Usage: "/register", Description: "Create an account", PrivateOnly: true,
+		Handler: t.register})
+	r.Register(Command{Name: "cancel", Usage: "/cancel", Description: "Stop the current conversation", PrivateOnly: true,
+		Handler: t.cancel})
+	r.Register(Command{Name: "logout", Usage: "/logout", Description: "Log out to switch accounts", PrivateOnly: true,
+		Handler: t.logout})
+	r.Register(Command{Name: "fav", Usage: "/fav [list|add <city>|remove <city>]",
+		Description: "Weather in all your favorite cities at once", RequiresAuth: true, PrivateOnly: true,
+		Handler: t.favorites})
+	r.Register(Command{Name: "daily", Usage: "/daily [HH:MM [city]]", Description: "Get the weather every day at a local time",
+		RequiresAuth: true, PrivateOnly: true, Handler: t.daily})
+	r.Register(Command{Name: "unsubscribe", Usage: "/unsubscribe", Description: "Stop the daily weather",
+		PrivateOnly: true, Handler: t.unsubscribe})
+	r.Register(Command{Name: "alert", Usage: "/alert <frost|rain|wind> <city> [threshold]",
+		Description: "Warn me when frost, heavy rain or strong wind starts", RequiresAuth: true, PrivateOnly: true,
+		Handler: t.addAlert})
+	r.Register(Command{Name: "alerts", Usage: "/alerts", Description: "List your alerts", PrivateOnly: true,
+		Handler: t.listAlerts})
+	r.Register(Command{Name: "unalert", Usage: "/unalert <frost|rain|wind> <city>", Description: "Remove an alert",
+		PrivateOnly: true, Handler: t.removeAlert})
+	r.Register(Command{Name: "quiet", Usage: "/quiet [HH:MM-HH:MM|off]", Description: "Set hours without alerts",
+		PrivateOnly: true, Handler: t.quiet})
+	r.Register(Command{Name: "settings", Usage: "/settings", Description: "Change units and language",
+		RequiresAuth: true, PrivateOnly: true, Handler: t.settings})
+	r.Register(Command{Name: "home", Usage: "/home [city]", Description: "Set your home city, or the group's city",
+		RequiresAuth: true, Handler: t.home})
+	r.Register(Command{Name: "timezone", Usage: "/timezone [zone]", Description: "Set your time zone",
+		PrivateOnly: true, Handler: t.timezone})
+
+	r.Register(Command{Name: "stats", Usage: "/stats", Description: "Sessions, load and errors", AdminOnly: true,
+		PrivateOnly: true, Handler: t.statsCommand})
+	r.Register(Command{Name: "broadcast", Usage: "/broadcast <text>", Description: "Send a message to every chat",
+		AdminOnly: true, PrivateOnly: true, Handler: t.broadcast})
+	r.Register(Command{Name: "kick", Usage: "/kick <chat id>", Description: "End a chat's session", AdminOnly: true,
+		PrivateOnly: true, Handler: t.kick})
+	r.Register(Command{Name: "health", Usage: "/health", Description: "Check the services the bot depends on",
+		AdminOnly: true, PrivateOnly: true, Handler: t.health})
+
+	r.RegisterCallback(Callback{Action: actionWeather, RequiresAuth: true, Handler: t.weatherCallback})
+	r.RegisterCallback(Callback{Action: actionForecast, RequiresAuth: true, Handler: t.forecastCallback})
+	r.RegisterCallback(Callback{Action: actionSettings, RequiresAuth: true, Handler: t.settingsCallback})
+
+	r.Admins(t.isAdmin)
+	r.Groups(t.bot.Self.UserName, t.privateOnly)
+	r.Location(t.location)
+	r.Fallback(t.text)
+	return r
+}
+
+func (t *Telegram) start(msg *tgbotapi.Message, _ string) error {
+	text := "Hi! I can tell you the weather in any city.\n\n" + t.router.Help()
+	if isGroup(msg.Chat) {
+		return t.reply(msg, fmt.Sprintf("Hi! Ask me about the weather with /weather <city> or mention me: @%s Minsk.\n"+
+			"Everyone logs in on their own in a private chat with me, and group admins can set the group's city with /home <city>",
+			t.bot.Self.UserName))
+	}
+	if !t.authService.CheckAuth(msg.Chat.ID) {
+		return t.reply(msg, text+"\n\n"+loginRequired)
+	}
+	return t.replyWithKeyboard(msg, text+"\n\nShare your location to get the local weather", locationKeyboard())
+}
+
+func (t *Telegram) help(msg *tgbotapi.Message, _ string) error {
+	if t.isAdmin(sender(msg)) {
+		return t.reply(msg, t.router.Help()+"\n\n"+t.router.AdminHelp())
+	}
+	return t.reply(msg, t.router.Help())
+}
+
+func (t *Telegram) needLogin(msg *tgbotapi.Message, _ string) error {
+	if isGroup(msg.Chat) {
+		return t.reply(msg, t.loginInPrivate())
+	}
+	return t.reply(msg, loginRequired)
+}
+
+// loginInPrivate keeps passwords out of groups.
+func (t *Telegram) loginInPrivate() string {
+	return "First of all, log in in a private chat with me: https://t.me/" + t.bot.Self.UserName
+}
+
+// privateOnly answers the commands that do not work in groups.
+func (t *Telegram) privateOnly(msg *tgbotapi.Message, _ string) error {
+	return t.reply(msg, "This works only in a private chat with me: https://t.me/"+t.bot.Self.UserName)
+}
+
+func (t *Telegram) weather(msg *tgbotapi.Message, city string) error {
+	q := t.query(msg.Chat.ID, city)
+	if city == "" {
+		q = t.homeQuery(msg.Chat.ID)
+	}
+	if q.City == "" {
+		cities := t.recent.list(msg.Chat.ID)
+		if len(cities) == 0 {
+			return t.reply(msg, "Write city please\nFor example: /weather Minsk, or set your home city with /home Minsk")
+		}
+		return t.replyWithKeyboard(msg, "Pick a city or write another one", citiesKeyboard(cities, q.Units))
+	}
+
+	ctx, err := t.authService.Context(context.Background(), sender(msg))
+	if err != nil {
+		return t.sessionError(msg, err)
+	}
+
+	// The home city was resolved when it was set.
+	if city != "" {
+		places, err := t.tgService.Geocode(ctx, city)
+		if err == nil && len(places) > 1 {
+			return t.replyWithKeyboard(msg, fmt.Sprintf("There are several places called %s. Which one?", city),
+				placesKeyboard(places, q.Units))
+		}
+	}
+
+	message, err := t.tgService.GetWeather(ctx, q)
+	if err != nil || message == "" {
+		return t.reply(msg, "Incorrect input")
+	}
+
+	t.recent.add(msg.Chat.ID, q.City)
+	return t.replyWithKeyboard(msg, message, weatherKeyboard(q))
+}
+
+// location answers a shared location or venue with the weather there.
+func (t *Telegram) location(msg *tgbotapi.Message, _ string) error {
+	q := t.query(msg.Chat.ID, "")
+	q.Coord = &service.Coord{Lat: msg.Location.Latitude, Lon: msg.Location.Longitude}
+	if msg.Venue != nil {
+		q.City = msg.Venue.Title
+	}
+
+	ctx, err := t.authService.Context(context.Background(), msg.Chat.ID)
+	if err != nil {
+		return t.sessionError(msg, err)
+	}
+
+	message, err := t.tgService.GetWeather(ctx, q)
+	if err != nil || message == "" {
+		return t.reply(msg, "Weather is not available for this place")
+	}
+	return t.replyWithKeyboard(msg, message, weatherKeyboard(q))
+}
+
+func (t *Telegram) forecast(msg *tgbotapi.Message, args string) error {
+	city, days, err := parseForecastArgs(args, t.prefs.Get(msg.Chat.ID).HomeCity)
+	if err != nil {
+		return t.reply(msg, fmt.Sprintf("Incorrect input: %s\nFor example: /forecast Minsk 5", err))
+	}
+
+	ctx, err := t.authService.Context(context.Background(), sender(msg))
+	if err != nil {
+		return t.sessionError(msg, err)
+	}
+
+	q := t.query(msg.Chat.ID, city)
+	if strings.EqualFold(city, t.prefs.Get(msg.Chat.ID).HomeCity) {
+		q = t.homeQuery(msg.Chat.ID)
+	}
+	message, err := t.tgService.GetForecast(ctx, q, days)
+	if err != nil || message == "" {
+		message = "Incorrect input"
+	}
+	return t.reply(msg, message)
+}
+
+func (t *Telegram) logout(msg *tgbotapi.Message, _ string) error {
+	if !t.authService.Logout(msg.Chat.ID) {
+		return t.reply(msg, "You are not logged in")
+	}
+	return t.replyWithKeyboard(msg, "You are logged out. Use /login to sign in again, possibly with another account",
+		tgbotapi.NewRemoveKeyboard(false))
+}
+
+func (t *Telegram) cancel(msg *tgbotapi.Message, _ string) error {
+	return t.dialogs.Cancel(t.bot, msg)
+}
+
+// registerDialogs adds the conversations of the bot.
+func (t *Telegram) registerDialogs() {
+	t.dialogs.Register(t.loginDialog())
+	t.dialogs.Register(t.registerDialog())
+	t.dialogs.Register(t.dailyDialog())
+}
+
+// sessionError asks to log in again when the session is gone.
+func (t *Telegram) sessionError(msg *tgbotapi.Message, err error) error {
+	if errors.Is(err, service.ErrNotLoggedIn) && isGroup(msg.Chat) {
+		return t.reply(msg, "Your session has expired.\n"+t.loginInPrivate())
+	}
+	if errors.Is(err, service.ErrNotLoggedIn) {
+		return t.reply(msg, "Your session has expired.\n"+loginRequired)
+	}
+	return err
+}
+
+// text keeps the original conversation working: credentials until the chat
+// is authorized, city names afterwards. Groups never take credentials.
+func (t *Telegram) text(msg *tgbotapi.Message, text string) error {
+	if isGroup(msg.Chat) {
+		if !t.authService.CheckAuth(sender(msg)) {
+			return t.needLogin(msg, "")
+		}
+		return t.weather(msg, text)
+	}
+
+	handled, err := t.dialogs.Handle(t.bot, msg)
+	if handled {
+		return err
+	}
+
+	if t.authService.CheckAuth(msg.Chat.ID) {
+		return t.weather(msg, text)
+	}
+
+	// Text that cannot be credentials, like a city, is neither deleted nor
+	// counted as a failed login.
+	login, password, err := service.SplitString(text)
+	if err != nil {
+		return t.reply(msg, loginRequired)
+	}
+	return t.attemptLogin(t.bot, msg, login, func() error { return t.authService.Login(login, password, msg.Chat.ID) })
+}
+
+// parseForecastArgs splits "[city] [days]". Without a city the home city is
+// used.
+func parseForecastArgs(args, home string) (string, int, error) {
+	fields := strings.Fields(args)
+
+	days := defaultForecastDays
+	if len(fields) > 1 || (len(fields) == 1 && home != "") {
+		n, err := strconv.Atoi(fields[len(fields)-1])
+		if err == nil {
+			if n < 1 || n > maxForecastDays {
+				return "", 0, fmt.Errorf("days must be between 1 and %d", maxForecastDays)
+			}
+			days = n
+			fields = fields[:len(fields)-1]
+		}
+	}
+
+	city := strings.Join(fields, " ")
+	if city == "" {
+		city = home
+	}
+	if city == "" {
+		return "", 0, errors.New("write city please")
+	}
+	return city, days, nil
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"strings"
+)
+
+const minInlineQuery = 2
+
+// inlineWeather is what an inline query for a city answers with. It is cached
+// by the query.
+type inlineWeather struct {
+	Current  string
+	Forecast string
+}
+
+// handleInlineQuery answers "@bot <city>" with the current weather and a short
+// forecast, which the user can post into any chat. Users must have logged in
+// to the bot in a private chat first.
+func (t *Telegram) handleInlineQuery(q *tgbotapi.InlineQuery) {
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: q.ID,
+		Results:       []interface{}{},
+		IsPersonal:    true,
+	}
+
+	city := strings.TrimSpace(q.Query)
+	switch {
+	case !t.authService.CheckAuth(q.From.ID):
+		answer.SwitchPMText = "Log in to get the weather"
+		answer.SwitchPMParameter = "login"
+	case len([]rune(city)) < minInlineQuery:
+	default:
+		w, err := t.inlineWeather(q.From.ID, city)
+		if err != nil {
+			log.Printf("Failed to answer inline query %q: %v", city, err)
+			break
+		}
+		answer.Results = inlineResults(city, w)
+		answer.CacheTime = int(t.cfg.Inline.CacheTTL.Seconds())
+	}
+
+	_, err := t.bot.Request(answer)
+	if err != nil {
+		log.Printf("Failed to answer inline query: %v", err)
+	}
+}
+
+// inlineWeather fetches the current weather and the forecast side by side.
+func (t *Telegram) inlineWeather(userID int64, city string) (inlineWeather, error) {
+	q := t.query(userID, city)
+	key := strings.ToLower(q.Units + "/" + q.Lang + "/" + city)
+	if w, ok := t.inlineCache.Get(key); ok {
+		return w, nil
+	}
+
+	ctx, err := t.authService.Context(context.Background(), userID)
+	if err != nil {
+		return inlineWeather{}, err
+	}
+
+	forecast := make(chan string, 1)
+	go func() {
+		// A failed forecast leaves just the current weather.
+		text, _ := t.tgService.GetForecast(ctx, q, keyboardForecastDays)
+		forecast <- text
+	}()
+
+	current, err := t.tgService.GetWeather(ctx, q)
+	w := inlineWeather{Current: current, Forecast: <-forecast}
+	if err != nil {
+		return inlineWeather{}, err
+	}
+	if w.Current == "" && w.Forecast == "" {
+		return inlineWeather{}, fmt.Errorf("no weather for %s", city)
+	}
+
+	t.inlineCache.Set(key, w)
+	return w, nil
+}
+
+func inlineResults(city string, w inlineWeather) []interface{} {
+	var results []interface{}
+	if w.Current != "" {
+		article := tgbotapi.NewInlineQueryResultArticle("now", "Now in "+city, w.Current)
+		article.Description = w.Current
+		results = append(results, article)
+	}
+	if w.Forecast != "" {
+		article := tgbotapi.NewInlineQueryResultArticle("forecast",
+			fmt.Sprintf("%d-day forecast for %s", keyboardForecastDays, city), w.Forecast)
+		article.Description = strings.ReplaceAll(w.Forecast, "\n", "; ")
+		results = append(results, article)
+	}
+	return results
+}
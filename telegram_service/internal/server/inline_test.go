@@ -0,0 +1,26 @@
+package server
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestInlineResults(t *testing.T) {
+	results := inlineResults("Minsk", inlineWeather{
+		Current:  "City: Minsk, Temp: 21.0 °C",
+		Forecast: "City: Minsk\nMon 01 May: 10..20 °C",
+	})
+	assert.Len(t, results, 2)
+
+	now := results[0].(tgbotapi.InlineQueryResultArticle)
+	assert.Equal(t, "now", now.ID)
+	assert.Equal(t, "Now in Minsk", now.Title)
+	assert.Equal(t, tgbotapi.InputTextMessageContent{Text: "City: Minsk, Temp: 21.0 °C"}, now.InputMessageContent)
+
+	forecast := results[1].(tgbotapi.InlineQueryResultArticle)
+	assert.Equal(t, "3-day forecast for Minsk", forecast.Title)
+	assert.Equal(t, "City: Minsk; Mon 01 May: 10..20 °C", forecast.Description)
+
+	assert.Len(t, inlineResults("Minsk", inlineWeather{Current: "City: Minsk"}), 1)
+}
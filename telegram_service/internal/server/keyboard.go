@@ -0,0 +1,246 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strconv"
+	"strings"
+	"sync"
+	"telegram_service/internal/service"
+)
+
+// Callback actions. The data after the action is an encoded service.Query.
+const (
+	actionWeather  = "w"
+	actionForecast = "f"
+)
+
+const (
+	keyboardForecastDays = 3
+	// maxCallbackData is the Bot API limit for callback data, in bytes.
+	maxCallbackData = 64
+	maxRecentCities = 5
+)
+
+// encodeQuery packs q into callback data as "units|lat,lon|city".
+func encodeQuery(q service.Query) string {
+	units := "m"
+	if q.Units == service.UnitsImperial {
+		units = "i"
+	}
+
+	var coord string
+	if q.Coord != nil {
+		coord = fmt.Sprintf("%.4f,%.4f", q.Coord.Lat, q.Coord.Lon)
+	}
+	return units + "|" + coord + "|" + q.City
+}
+
+func decodeQuery(data string) (service.Query, error) {
+	parts := strings.SplitN(data, "|", 3)
+	if len(parts) != 3 {
+		return service.Query{}, fmt.Errorf("malformed query %q", data)
+	}
+
+	q := service.Query{City: parts[2], Units: service.UnitsMetric}
+	switch parts[0] {
+	case "m":
+	case "i":
+		q.Units = service.UnitsImperial
+	default:
+		return service.Query{}, fmt.Errorf("unknown units in query %q", data)
+	}
+
+	if parts[1] != "" {
+		lat, lon, ok := strings.Cut(parts[1], ",")
+		if !ok {
+			return service.Query{}, fmt.Errorf("malformed coordinates in query %q", data)
+		}
+		var c service.Coord
+		var err error
+		c.Lat, err = strconv.ParseFloat(lat, 64)
+		if err == nil {
+			c.Lon, err = strconv.ParseFloat(lon, 64)
+		}
+		if err != nil {
+			return service.Query{}, fmt.Errorf("malformed coordinates in query %q: %w", data, err)
+		}
+		q.Coord = &c
+	}
+	return q, nil
+}
+
+// addButton appends a button unless its callback data does not fit into
+// Telegram's limit.
+func addButton(row []tgbotapi.InlineKeyboardButton, text, action string, q service.Query) []tgbotapi.InlineKeyboardButton {
+	data := action + ":" + encodeQuery(q)
+	if len(data) > maxCallbackData {
+		return row
+	}
+	return append(row, tgbotapi.NewInlineKeyboardButtonData(text, data))
+}
+
+// keyboard drops empty rows. It returns nil when no button is left.
+func keyboard(rows ...[]tgbotapi.InlineKeyboardButton) *tgbotapi.InlineKeyboardMarkup {
+	var res [][]tgbotapi.InlineKeyboardButton
+	for _, row := range rows {
+		if len(row) > 0 {
+			res = append(res, row)
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(res...)
+	return &markup
+}
+
+func withUnits(q service.Query, units string) service.Query {
+	q.Units = units
+	return q
+}
+
+// toggleUnits returns the button text and units to switch q to.
+func toggleUnits(q service.Query) (string, string) {
+	if q.Units == service.UnitsImperial {
+		return "°C", service.UnitsMetric
+	}
+	return "°F", service.UnitsImperial
+}
+
+// weatherKeyboard goes under current weather replies.
+func weatherKeyboard(q service.Query) *tgbotapi.InlineKeyboardMarkup {
+	text, units := toggleUnits(q)
+
+	var row []tgbotapi.InlineKeyboardButton
+	row = addButton(row, "🔄 Refresh", actionWeather, q)
+	row = addButton(row, fmt.Sprintf("📅 %d days", keyboardForecastDays), actionForecast, q)
+	row = addButton(row, text, actionWeather, withUnits(q, units))
+	return keyboard(row)
+}
+
+// forecastKeyboard goes under forecasts opened from a weather reply.
+func forecastKeyboard(q service.Query) *tgbotapi.InlineKeyboardMarkup {
+	text, units := toggleUnits(q)
+
+	var row []tgbotapi.InlineKeyboardButton
+	row = addButton(row, "🌡 Now", actionWeather, q)
+	row = addButton(row, "🔄 Refresh", actionForecast, q)
+	row = addButton(row, text, actionForecast, withUnits(q, units))
+	return keyboard(row)
+}
+
+// citiesKeyboard offers one button per city.
+func citiesKeyboard(cities []string, units string) *tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(cities))
+	for _, city := range cities {
+		rows = append(rows, addButton(nil, city, actionWeather, service.Query{City: city, Units: units}))
+	}
+	return keyboard(rows...)
+}
+
+// placesKeyboard lets the user pick one of the places sharing a name.
+func placesKeyboard(places []service.Place, units string) *tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(places))
+	for _, p := range places {
+		coord := p.Coord
+		q := service.Query{City: p.Name, Units: units, Coord: &coord}
+		rows = append(rows, addButton(nil, p.Label(), actionWeather, q))
+	}
+	return keyboard(rows...)
+}
+
+// locationKeyboard is a reply keyboard that shares the location in one tap.
+// Telegram shows it in private chats only.
+func locationKeyboard() tgbotapi.ReplyKeyboardMarkup {
+	markup := tgbotapi.NewReplyKeyboard(tgbotapi.NewKeyboardButtonRow(
+		tgbotapi.NewKeyboardButtonLocation("📍 Weather here"),
+	))
+	markup.ResizeKeyboard = true
+	return markup
+}
+
+func (t *Telegram) weatherCallback(cb *tgbotapi.CallbackQuery, data string) error {
+	q, err := decodeQuery(data)
+	if err != nil {
+		return err
+	}
+	q.Lang = t.prefs.Get(cb.Message.Chat.ID).Language
+
+	ctx, err := t.authService.Context(context.Background(), callbackSender(cb))
+	if err != nil {
+		return t.sessionError(cb.Message, err)
+	}
+
+	message, err := t.tgService.GetWeather(ctx, q)
+	if err != nil || message == "" {
+		return t.edit(cb.Message, "Incorrect input", nil)
+	}
+	return t.edit(cb.Message, message, weatherKeyboard(q))
+}
+
+func (t *Telegram) forecastCallback(cb *tgbotapi.CallbackQuery, data string) error {
+	q, err := decodeQuery(data)
+	if err != nil {
+		return err
+	}
+	q.Lang = t.prefs.Get(cb.Message.Chat.ID).Language
+
+	ctx, err := t.authService.Context(context.Background(), callbackSender(cb))
+	if err != nil {
+		return t.sessionError(cb.Message, err)
+	}
+
+	message, err := t.tgService.GetForecast(ctx, q, keyboardForecastDays)
+	if err != nil || message == "" {
+		return t.edit(cb.Message, "Incorrect input", nil)
+	}
+	return t.edit(cb.Message, message, forecastKeyboard(q))
+}
+
+// edit replaces the text and buttons of a message the bot sent.
+func (t *Telegram) edit(msg *tgbotapi.Message, text string, markup *tgbotapi.InlineKeyboardMarkup) error {
+	cfg := tgbotapi.NewEditMessageText(msg.Chat.ID, msg.MessageID, text)
+	cfg.ReplyMarkup = markup
+
+	_, err := t.bot.Send(cfg)
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && strings.Contains(apiErr.Message, "message is not modified") {
+		// Refreshing weather that has not changed yet.
+		return nil
+	}
+	return err
+}
+
+// recentCities remembers the cities a chat asked about, latest first, to
+// offer them as quick picks.
+type recentCities struct {
+	mu     sync.Mutex
+	cities map[int64][]string
+}
+
+func newRecentCities() *recentCities {
+	return &recentCities{cities: map[int64][]string{}}
+}
+
+func (r *recentCities) add(chatID int64, city string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cities := []string{city}
+	for _, c := range r.cities[chatID] {
+		if !strings.EqualFold(c, city) && len(cities) < maxRecentCities {
+			cities = append(cities, c)
+		}
+	}
+	r.cities[chatID] = cities
+}
+
+func (r *recentCities) list(chatID int64) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.cities[chatID]...)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"telegram_service/internal/service"
+	"testing"
+)
+
+func TestQuery_RoundTrip(t *testing.T) {
+	var useCase = []struct {
+		Name  string
+		Query service.Query
+		Data  string
+	}{
+		{Name: "City", Query: service.Query{City: "Minsk", Units: service.UnitsMetric}, Data: "m||Minsk"},
+		{Name: "Imperial", Query: service.Query{City: "New York", Units: service.UnitsImperial}, Data: "i||New York"},
+		{Name: "City with separator", Query: service.Query{City: "a|b", Units: service.UnitsMetric}, Data: "m||a|b"},
+		{
+			Name:  "Coordinates",
+			Query: service.Query{City: "London", Units: service.UnitsMetric, Coord: &service.Coord{Lat: 42.9834, Lon: -81.2330}},
+			Data:  "m|42.9834,-81.2330|London",
+		},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			assert.Equal(t, us.Data, encodeQuery(us.Query))
+			q, err := decodeQuery(us.Data)
+			assert.NoError(t, err)
+			assert.Equal(t, us.Query, q)
+		})
+	}
+
+	for _, data := range []string{"", "m|Minsk", "x||Minsk", "m|1|Minsk", "m|a,b|Minsk"} {
+		_, err := decodeQuery(data)
+		assert.Error(t, err, data)
+	}
+}
+
+func TestWeatherKeyboard(t *testing.T) {
+	markup := weatherKeyboard(service.Query{City: "Minsk", Units: service.UnitsMetric})
+	assert.Len(t, markup.InlineKeyboard, 1)
+
+	var data []string
+	for _, b := range markup.InlineKeyboard[0] {
+		data = append(data, *b.CallbackData)
+	}
+	assert.Equal(t, []string{"w:m||Minsk", "f:m||Minsk", "w:i||Minsk"}, data)
+	assert.Equal(t, "°F", markup.InlineKeyboard[0][2].Text)
+
+	long := service.Query{City: strings.Repeat("x", maxCallbackData), Units: service.UnitsMetric}
+	assert.Nil(t, weatherKeyboard(long), "buttons that do not fit are left out")
+
+	markup = citiesKeyboard([]string{"Minsk", long.City, "Brest"}, service.UnitsMetric)
+	assert.Len(t, markup.InlineKeyboard, 2)
+}
+
+func TestRecentCities(t *testing.T) {
+	r := newRecentCities()
+	for _, city := range []string{"Minsk", "Brest", "Grodno", "minsk", "Gomel", "Vitebsk", "Mogilev"} {
+		r.add(1, city)
+	}
+
+	assert.Equal(t, []string{"Mogilev", "Vitebsk", "Gomel", "minsk", "Grodno"}, r.list(1))
+	assert.Empty(t, r.list(2))
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"net/http"
+	"telegram_service/internal/ratelimit"
+	"time"
+)
+
+// throttled returns the job that handles an update over the chat's rate
+// limit instead, nil to drop it. Messages get a warning once, buttons always
+// get a toast so they stop spinning.
+// mayHoldPassword reports whether the message may carry a password: any
+// message of a private chat in a dialog, and text or /login of a chat that is
+// not logged in. They reach their handler even when the chat is throttled,
+// so the password gets deleted.
+func (t *Telegram) mayHoldPassword(update tgbotapi.Update) bool {
+	msg := update.Message
+	if msg == nil || isGroup(msg.Chat) {
+		return false
+	}
+	if _, ok := t.dialogs.Active(msg.Chat.ID); ok {
+		return true
+	}
+	return (!msg.IsCommand() || msg.Command() == "login") && !t.authService.CheckAuth(msg.Chat.ID)
+}
+
+func (t *Telegram) throttled(update tgbotapi.Update, r ratelimit.Result) func() {
+	text := "You are sending messages too fast. Please wait " + waitText(r.RetryAfter)
+	switch {
+	case update.Message != nil && r.Warn:
+		msg := update.Message
+		return func() {
+			err := t.reply(msg, text)
+			if err != nil {
+				log.Printf("Failed to send reply: %v", err)
+			}
+		}
+	case update.CallbackQuery != nil:
+		cb := update.CallbackQuery
+		return func() {
+			_, err := t.bot.Request(tgbotapi.NewCallback(cb.ID, text))
+			if err != nil {
+				log.Printf("Failed to answer button: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func lockedOut(wait time.Duration) string {
+	return "Too many failed login attempts. Try again in " + waitText(wait)
+}
+
+// waitText rounds up to seconds under a minute and to minutes above.
+func waitText(d time.Duration) string {
+	if d <= time.Minute {
+		seconds := int((d + time.Second - 1) / time.Second)
+		if seconds <= 1 {
+			return "a second"
+		}
+		return fmt.Sprintf("%d seconds", seconds)
+	}
+
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+// serveMetrics serves the expvar counters, the rate limits' among them, until
+// ctx is done.
+func (t *Telegram) serveMetrics(ctx context.Context) {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	srv := &http.Server{Addr: t.cfg.Metrics.Address, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err := srv.Shutdown(shutdownCtx)
+		if err != nil {
+			log.Printf("Failed to stop metrics server: %v", err)
+		}
+	}()
+
+	log.Printf("Serving metrics on %s/debug/vars", t.cfg.Metrics.Address)
+	err := srv.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Metrics server failed: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"errors"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"strings"
+	"telegram_service/internal/dialog"
+	"telegram_service/internal/service"
+	"time"
+)
+
+// loginTimeout is how long the bot waits for the next step of /login.
+const loginTimeout = 5 * time.Minute
+
+const (
+	dialogLogin  = "login"
+	stepLogin    = "login"
+	stepPassword = "password"
+)
+
+const (
+	askLogin       = "Send your login"
+	askPassword    = "Now send your password. I will delete the message right after checking it"
+	cannotDelete   = "I could not delete the message with your password. Please delete it yourself"
+	cannotLogin    = "I could not check your login right now. Please try again later"
+	loginCancelled = "Login timed out. Send /login to try again"
+)
+
+// login starts a session. Credentials may come in the command itself or step
+// by step: /login, then the login, then the password.
+func (t *Telegram) login(msg *tgbotapi.Message, args string) error {
+	t.authService.Logout(msg.Chat.ID)
+
+	fields := strings.Fields(args)
+	switch len(fields) {
+	case 0:
+		err := t.dialogs.Begin(msg.Chat.ID, dialogLogin, stepLogin, nil)
+		if err != nil {
+			return err
+		}
+		return t.reply(msg, askLogin)
+	case 1:
+		err := t.dialogs.Begin(msg.Chat.ID, dialogLogin, stepPassword, map[string]string{stepLogin: fields[0]})
+		if err != nil {
+			return err
+		}
+		return t.reply(msg, askPassword)
+	}
+
+	return t.attemptLogin(t.bot, msg, fields[0], func() error { return t.authService.Auth(args, msg.Chat.ID) })
+}
+
+// loginDialog asks for the login, then the password.
+func (t *Telegram) loginDialog() *dialog.Dialog {
+	return &dialog.Dialog{
+		Name:    dialogLogin,
+		Timeout: loginTimeout,
+		Expired: loginCancelled,
+		Steps: map[string]dialog.StepFunc{
+			stepLogin:    t.askedLogin,
+			stepPassword: t.askedPassword,
+		},
+		Sensitive: map[string]bool{stepPassword: true},
+	}
+}
+
+func (t *Telegram) askedLogin(c *dialog.Conversation, text string) error {
+	login := strings.TrimSpace(text)
+	if login == "" {
+		return c.Reply(askLogin)
+	}
+
+	c.Set(stepLogin, login)
+	c.Next(stepPassword)
+	return c.Reply(askPassword)
+}
+
+func (t *Telegram) askedPassword(c *dialog.Conversation, text string) error {
+	c.Finish()
+	return t.attemptLogin(c.Bot, c.Message, c.Get(stepLogin), func() error {
+		return t.authService.Login(c.Get(stepLogin), text, c.Message.Chat.ID)
+	})
+}
+
+// attemptLogin checks the credentials in msg for the account with try, unless
+// the chat or the account is locked out after too many failed attempts. The
+// account is locked out too, so guessing a password from many chats does not
+// help. Only rejected credentials count as failed attempts, not an
+// unavailable user service.
+func (t *Telegram) attemptLogin(bot dialog.Bot, msg *tgbotapi.Message, login string, try func() error) error {
+	chatID := msg.Chat.ID
+	account := strings.ToLower(login)
+	if wait := maxDuration(t.logins.Locked(chatID), t.accounts.Locked(account)); wait > 0 {
+		return checkCredentials(bot, msg, false, lockedOut(wait))
+	}
+
+	err := try()
+	switch {
+	case err == nil:
+		t.logins.Succeed(chatID)
+		t.accounts.Succeed(account)
+		return checkCredentials(bot, msg, true, "")
+	case errors.Is(err, service.ErrWrongCredentials):
+		failure := loginRequired
+		if wait := maxDuration(t.logins.Fail(chatID), t.accounts.Fail(account)); wait > 0 {
+			failure = lockedOut(wait)
+		}
+		return checkCredentials(bot, msg, false, failure)
+	case errors.Is(err, service.ErrMalformedCredentials):
+		return checkCredentials(bot, msg, false, loginRequired)
+	}
+
+	log.Printf("Failed to log in chat %d: %v", chatID, err)
+	return checkCredentials(bot, msg, false, cannotLogin)
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// checkCredentials removes the message with the password from the chat and
+// reports the result of the login attempt, failure when it failed.
+func checkCredentials(bot dialog.Bot, msg *tgbotapi.Message, ok bool, failure string) error {
+	m := tgbotapi.NewMessage(msg.Chat.ID, failure)
+	if ok {
+		m.Text = loginSuccess
+		m.ReplyMarkup = locationKeyboard()
+	}
+
+	_, err := bot.Request(tgbotapi.NewDeleteMessage(msg.Chat.ID, msg.MessageID))
+	if err != nil {
+		log.Printf("Failed to delete credentials in chat %d: %v", msg.Chat.ID, err)
+		m.Text += "\n\n" + cannotDelete
+		m.ReplyToMessageID = msg.MessageID
+	}
+
+	_, err = bot.Send(m)
+	return err
+}
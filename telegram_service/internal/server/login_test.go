@@ -0,0 +1,54 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"telegram_service/internal/dialog/dialogtest"
+	"testing"
+)
+
+func TestLoginDialog(t *testing.T) {
+	tg := &Telegram{}
+	h := dialogtest.New(t, tg.loginDialog())
+
+	assert.False(t, h.Send("name"), "no dialog before /login")
+
+	h.Begin(dialogLogin, stepLogin, nil)
+	h.Send(" ")
+	h.Expect(askLogin)
+	h.Send("name")
+	h.Expect(askPassword)
+
+	name, ok := h.Active()
+	assert.True(t, ok)
+	assert.Equal(t, dialogLogin, name)
+
+	h.Advance(loginTimeout)
+	assert.True(t, h.Send("password"))
+	h.Expect(loginCancelled)
+	assert.Equal(t, []int{4}, h.Deleted(), "a late password is deleted")
+	_, ok = h.Active()
+	assert.False(t, ok)
+
+	h.Begin(dialogLogin, stepPassword, map[string]string{stepLogin: "name"})
+	h.Send("/cancel")
+	h.Expect("Cancelled")
+	assert.False(t, h.Send("password"), "the password is not taken after /cancel")
+}
+
+func TestRedacted(t *testing.T) {
+	var useCase = []struct {
+		Name   string
+		Text   string
+		Expect string
+	}{
+		{Name: "Login with credentials", Text: "/login alice secret123", Expect: "/login, 22 characters"},
+		{Name: "Command addressed to the bot", Text: "/register@weather_bot", Expect: "/register, 21 characters"},
+		{Name: "Password step", Text: "pässword", Expect: "message, 8 characters"},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			assert.Equal(t, us.Expect, redacted(message(1, us.Text)))
+		})
+	}
+}
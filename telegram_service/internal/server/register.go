@@ -0,0 +1,153 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"regexp"
+	"strings"
+	"telegram_service/internal/dialog"
+	"time"
+)
+
+const (
+	dialogRegister = "register"
+	stepName       = "name"
+)
+
+// registerTimeout is how long the bot waits for the next step of /register.
+const registerTimeout = 10 * time.Minute
+
+const (
+	maxName     = 64
+	minPassword = 8
+)
+
+const (
+	askName             = "Let's create an account. What is your name?"
+	askNewLogin         = "Choose a login: 3 to 32 letters, digits, dots, dashes or underscores"
+	askNewPassword      = "Now choose a password of at least 8 characters, without spaces. I will delete the message right away"
+	registerCancelled   = "Registration timed out. Send /register to start again"
+	registerLoginTaken  = "This login is already taken. Choose another one"
+	registerSuccessText = "Welcome, %s! Your account is ready and you are logged in.\n" +
+		"Select the city where you want to know the weather. \nFor example: Minsk"
+)
+
+// loginPattern mirrors the user service's rules, to complain before the
+// password is asked.
+var loginPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,32}$`)
+
+// register asks for the name, login and password of a new account one by one.
+func (t *Telegram) register(msg *tgbotapi.Message, _ string) error {
+	if t.authService.CheckAuth(msg.Chat.ID) {
+		return t.reply(msg, "You are already logged in. Use /logout first to create another account")
+	}
+
+	err := t.dialogs.Begin(msg.Chat.ID, dialogRegister, stepName, nil)
+	if err != nil {
+		return err
+	}
+	return t.reply(msg, askName)
+}
+
+func (t *Telegram) registerDialog() *dialog.Dialog {
+	return &dialog.Dialog{
+		Name:    dialogRegister,
+		Timeout: registerTimeout,
+		Expired: registerCancelled,
+		Steps: map[string]dialog.StepFunc{
+			stepName:     t.askedName,
+			stepLogin:    t.askedNewLogin,
+			stepPassword: t.askedNewPassword,
+		},
+		Sensitive: map[string]bool{stepPassword: true},
+	}
+}
+
+func (t *Telegram) askedName(c *dialog.Conversation, text string) error {
+	name := strings.TrimSpace(text)
+	err := validateName(name)
+	if err != nil {
+		return c.Reply(fmt.Sprintf("Incorrect input: %s\n%s", err, askName))
+	}
+
+	c.Set(stepName, name)
+	c.Next(stepLogin)
+	return c.Reply(askNewLogin)
+}
+
+func (t *Telegram) askedNewLogin(c *dialog.Conversation, text string) error {
+	login := strings.TrimSpace(text)
+	if !loginPattern.MatchString(login) {
+		return c.Reply("Incorrect input\n" + askNewLogin)
+	}
+
+	c.Set(stepLogin, login)
+	c.Next(stepPassword)
+	return c.Reply(askNewPassword)
+}
+
+// askedNewPassword removes the message with the password and registers the
+// account. A taken login is asked again.
+func (t *Telegram) askedNewPassword(c *dialog.Conversation, password string) error {
+	chatID := c.Message.Chat.ID
+	_, err := c.Bot.Request(tgbotapi.NewDeleteMessage(chatID, c.Message.MessageID))
+	var warning string
+	if err != nil {
+		log.Printf("Failed to delete password in chat %d: %v", chatID, err)
+		warning = "\n\n" + cannotDelete
+	}
+
+	err = validatePassword(password)
+	if err != nil {
+		return send(c.Bot, chatID, fmt.Sprintf("Incorrect input: %s\n%s%s", err, askNewPassword, warning))
+	}
+
+	name := c.Get(stepName)
+	err = t.authService.Register(name, c.Get(stepLogin), password, chatID)
+	switch status.Code(err) {
+	case codes.OK:
+		c.Finish()
+		m := tgbotapi.NewMessage(chatID, fmt.Sprintf(registerSuccessText, name)+warning)
+		m.ReplyMarkup = locationKeyboard()
+		_, err = c.Bot.Send(m)
+		return err
+	case codes.AlreadyExists:
+		c.Next(stepLogin)
+		return send(c.Bot, chatID, registerLoginTaken+warning)
+	case codes.InvalidArgument:
+		c.Finish()
+		return send(c.Bot, chatID, fmt.Sprintf("Incorrect input: %s\nSend /register to start again%s",
+			status.Convert(err).Message(), warning))
+	}
+	c.Finish()
+	return err
+}
+
+func send(bot dialog.Bot, chatID int64, text string) error {
+	_, err := bot.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+func validateName(name string) error {
+	if name == "" || strings.HasPrefix(name, "/") {
+		return errors.New("write your name please")
+	}
+	if len([]rune(name)) > maxName {
+		return fmt.Errorf("the name must be at most %d characters long", maxName)
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	if len([]rune(password)) < minPassword {
+		return fmt.Errorf("the password must be at least %d characters long", minPassword)
+	}
+	if strings.ContainsAny(password, " \t\n") {
+		return errors.New("the password must not contain spaces")
+	}
+	return nil
+}
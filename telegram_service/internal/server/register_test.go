@@ -0,0 +1,70 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"telegram_service/internal/dialog/dialogtest"
+	"testing"
+)
+
+func TestRegisterDialog(t *testing.T) {
+	tg := &Telegram{}
+	h := dialogtest.New(t, tg.registerDialog())
+	h.Begin(dialogRegister, stepName, nil)
+
+	h.Send("/weather")
+	h.Expect("Incorrect input: write your name please")
+	h.Send("Ann")
+	h.Expect(askNewLogin)
+	h.Send("a b")
+	h.Expect("Incorrect input")
+	h.Send("ann")
+	h.Expect(askNewPassword)
+
+	h.Send("short")
+	h.Expect("Incorrect input: the password must be at least")
+	assert.Equal(t, []int{5}, h.Deleted(), "passwords are deleted even when rejected")
+
+	name, ok := h.Active()
+	assert.True(t, ok, "a rejected password is asked again")
+	assert.Equal(t, dialogRegister, name)
+
+	h.Advance(registerTimeout)
+	h.Send("long-enough")
+	h.Expect(registerCancelled)
+	assert.Equal(t, []int{6}, h.Deleted(), "a late password is deleted")
+	_, ok = h.Active()
+	assert.False(t, ok)
+}
+
+func TestRegisterValidation(t *testing.T) {
+	var useCase = []struct {
+		Name    string
+		Check   func(string) error
+		Input   string
+		IsError bool
+	}{
+		{Name: "Name", Check: validateName, Input: "Ann Smith"},
+		{Name: "Empty name", Check: validateName, Input: "", IsError: true},
+		{Name: "Command as name", Check: validateName, Input: "/weather", IsError: true},
+		{Name: "Long name", Check: validateName, Input: strings.Repeat("a", maxName+1), IsError: true},
+		{Name: "Password", Check: validatePassword, Input: "secret-password"},
+		{Name: "Short password", Check: validatePassword, Input: "secret", IsError: true},
+		{Name: "Password with spaces", Check: validatePassword, Input: "secret password", IsError: true},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			err := us.Check(us.Input)
+			if us.IsError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	assert.True(t, loginPattern.MatchString("ann.smith_1"))
+	assert.False(t, loginPattern.MatchString("an"))
+	assert.False(t, loginPattern.MatchString("ann smith"))
+}
@@ -0,0 +1,290 @@
+package server
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strings"
+	"unicode/utf16"
+)
+
+// Handler processes a message. args holds the text after the command name.
+type Handler func(msg *tgbotapi.Message, args string) error
+
+// CallbackHandler processes a button press. data holds the callback data
+// after the action name.
+type CallbackHandler func(cb *tgbotapi.CallbackQuery, data string) error
+
+// Callback handles buttons whose callback data is "<action>:<data>".
+type Callback struct {
+	Action       string
+	RequiresAuth bool
+	Handler      CallbackHandler
+}
+
+// Command is a slash command. AdminOnly commands are unknown to other chats
+// and left out of the help and the command menu. PrivateOnly commands are
+// refused in groups.
+type Command struct {
+	Name         string
+	Usage        string
+	Description  string
+	RequiresAuth bool
+	AdminOnly    bool
+	PrivateOnly  bool
+	Handler      Handler
+}
+
+// Router dispatches messages to registered commands. Shared locations go to
+// the location handler and need a login, other plain messages go to the
+// fallback handler. Logins are checked for the sender, so in groups every
+// member is authorized on their own.
+type Router struct {
+	commands     map[string]*Command
+	order        []*Command
+	callbacks    map[string]*Callback
+	fallback     Handler
+	location     Handler
+	authorized   func(id int64) bool
+	admin        func(id int64) bool
+	unauthorized Handler
+	botName      string
+	privateOnly  Handler
+}
+
+func NewRouter(authorized func(id int64) bool, unauthorized Handler) *Router {
+	return &Router{
+		commands:     map[string]*Command{},
+		callbacks:    map[string]*Callback{},
+		authorized:   authorized,
+		unauthorized: unauthorized,
+	}
+}
+
+func (r *Router) Register(c Command) {
+	if _, ok := r.commands[c.Name]; ok {
+		panic(fmt.Sprintf("command /%s registered twice", c.Name))
+	}
+	r.commands[c.Name] = &c
+	r.order = append(r.order, &c)
+}
+
+func (r *Router) RegisterCallback(c Callback) {
+	if _, ok := r.callbacks[c.Action]; ok {
+		panic(fmt.Sprintf("callback %s registered twice", c.Action))
+	}
+	r.callbacks[c.Action] = &c
+}
+
+// Fallback sets the handler for messages that are not commands.
+func (r *Router) Fallback(h Handler) {
+	r.fallback = h
+}
+
+// Admins sets who may run the admin commands. Without it nobody may.
+func (r *Router) Admins(admin func(id int64) bool) {
+	r.admin = admin
+}
+
+// Groups lets the bot work in groups, where it takes only commands, messages
+// mentioning @botName and replies to it. Private-only commands there go to
+// privateOnly. Without it all plain messages in groups are ignored.
+func (r *Router) Groups(botName string, privateOnly Handler) {
+	r.botName = botName
+	r.privateOnly = privateOnly
+}
+
+// Location sets the handler for shared locations and venues.
+func (r *Router) Location(h Handler) {
+	r.location = h
+}
+
+// ErrUnknownCommand is returned for commands nobody registered.
+type ErrUnknownCommand string
+
+func (e ErrUnknownCommand) Error() string {
+	return fmt.Sprintf("unknown command /%s", string(e))
+}
+
+func (r *Router) Dispatch(msg *tgbotapi.Message) error {
+	group := isGroup(msg.Chat)
+	if group && !msg.IsCommand() {
+		text, ok := r.addressed(msg)
+		if !ok || msg.Location != nil || r.fallback == nil {
+			return nil
+		}
+		return r.fallback(msg, text)
+	}
+
+	if msg.Location != nil && r.location != nil {
+		if !r.authorized(sender(msg)) {
+			return r.unauthorized(msg, "")
+		}
+		return r.location(msg, "")
+	}
+
+	if !msg.IsCommand() {
+		if r.fallback == nil {
+			return nil
+		}
+		return r.fallback(msg, strings.TrimSpace(msg.Text))
+	}
+
+	if group && !r.forBot(msg) {
+		return nil
+	}
+
+	c, ok := r.commands[msg.Command()]
+	if !ok || c.AdminOnly && !r.isAdmin(sender(msg)) {
+		if group && !strings.Contains(msg.CommandWithAt(), "@") {
+			// Likely meant for another bot in the group.
+			return nil
+		}
+		return ErrUnknownCommand(msg.Command())
+	}
+
+	if group && c.PrivateOnly {
+		return r.privateOnly(msg, "")
+	}
+
+	if c.RequiresAuth && !r.authorized(sender(msg)) {
+		return r.unauthorized(msg, "")
+	}
+	return c.Handler(msg, strings.TrimSpace(msg.CommandArguments()))
+}
+
+// ErrUnknownCallback is returned for buttons with an action nobody registered.
+type ErrUnknownCallback string
+
+func (e ErrUnknownCallback) Error() string {
+	return fmt.Sprintf("unknown callback action %q", string(e))
+}
+
+// DispatchCallback routes a button press by the action in its data.
+func (r *Router) DispatchCallback(cb *tgbotapi.CallbackQuery) error {
+	action, data, _ := strings.Cut(cb.Data, ":")
+
+	c, ok := r.callbacks[action]
+	if !ok {
+		return ErrUnknownCallback(action)
+	}
+
+	if c.RequiresAuth {
+		// Buttons under inline messages have no chat to check.
+		if cb.Message == nil {
+			return nil
+		}
+		if !r.authorized(callbackSender(cb)) {
+			return r.unauthorized(cb.Message, "")
+		}
+	}
+	return c.Handler(cb, data)
+}
+
+// addressed returns the text of a group message meant for the bot: one that
+// mentions it, with the mention cut out, or a reply to it.
+func (r *Router) addressed(msg *tgbotapi.Message) (string, bool) {
+	if r.botName == "" {
+		return "", false
+	}
+
+	// Telegram marks mentions with offsets in UTF-16 code units.
+	text := utf16.Encode([]rune(msg.Text))
+	for _, e := range msg.Entities {
+		if !e.IsMention() || e.Offset < 0 || e.Length < 0 || e.Offset+e.Length > len(text) {
+			continue
+		}
+		if !strings.EqualFold(string(utf16.Decode(text[e.Offset:e.Offset+e.Length])), "@"+r.botName) {
+			continue
+		}
+		rest := string(utf16.Decode(text[:e.Offset])) + " " + string(utf16.Decode(text[e.Offset+e.Length:]))
+		return strings.Join(strings.Fields(rest), " "), true
+	}
+
+	reply := msg.ReplyToMessage
+	if reply != nil && reply.From != nil && strings.EqualFold(reply.From.UserName, r.botName) {
+		return strings.TrimSpace(msg.Text), true
+	}
+	return "", false
+}
+
+// forBot reports whether a command in a group is not addressed to another
+// bot, as in /weather@other_bot.
+func (r *Router) forBot(msg *tgbotapi.Message) bool {
+	_, to, ok := strings.Cut(msg.CommandWithAt(), "@")
+	return !ok || strings.EqualFold(to, r.botName)
+}
+
+func (r *Router) isAdmin(id int64) bool {
+	return r.admin != nil && r.admin(id)
+}
+
+func (r *Router) Commands() []*Command {
+	return r.order
+}
+
+// BotCommands lists the commands in the form setMyCommands expects.
+func (r *Router) BotCommands() []tgbotapi.BotCommand {
+	return r.botCommands(false)
+}
+
+// GroupBotCommands lists the commands that work in groups.
+func (r *Router) GroupBotCommands() []tgbotapi.BotCommand {
+	return r.botCommands(true)
+}
+
+func (r *Router) botCommands(group bool) []tgbotapi.BotCommand {
+	res := make([]tgbotapi.BotCommand, 0, len(r.order))
+	for _, c := range r.order {
+		if !c.AdminOnly && !(group && c.PrivateOnly) {
+			res = append(res, tgbotapi.BotCommand{Command: c.Name, Description: c.Description})
+		}
+	}
+	return res
+}
+
+func (r *Router) Help() string {
+	return r.help("Available commands:", false)
+}
+
+// AdminHelp lists the admin commands.
+func (r *Router) AdminHelp() string {
+	return r.help("Admin commands:", true)
+}
+
+func (r *Router) help(title string, admin bool) string {
+	var b strings.Builder
+	b.WriteString(title)
+	for _, c := range r.order {
+		if c.AdminOnly != admin {
+			continue
+		}
+		b.WriteString("\n")
+		b.WriteString(c.Usage)
+		b.WriteString(" - ")
+		b.WriteString(c.Description)
+		if c.RequiresAuth {
+			b.WriteString(" (login required)")
+		}
+	}
+	return b.String()
+}
+
+func isGroup(chat *tgbotapi.Chat) bool {
+	return chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+}
+
+// sender is who sent the message: the user in groups, and in private chats
+// the chat itself, as they have the same ID.
+func sender(msg *tgbotapi.Message) int64 {
+	if msg.From != nil {
+		return msg.From.ID
+	}
+	return msg.Chat.ID
+}
+
+func callbackSender(cb *tgbotapi.CallbackQuery) int64 {
+	if cb.From != nil {
+		return cb.From.ID
+	}
+	return cb.Message.Chat.ID
+}
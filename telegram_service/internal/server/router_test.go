@@ -0,0 +1,296 @@
+package server
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"telegram_service/internal/alert"
+	"telegram_service/internal/telegramtest"
+	"testing"
+)
+
+const (
+	authorizedChat = 1
+	adminChat      = 3
+)
+
+// message builds an incoming message the way Telegram marks commands and
+// mentions.
+func message(chatID int64, text string) *tgbotapi.Message {
+	return &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}, Text: text, Entities: telegramtest.Entities(text)}
+}
+
+func TestRouter_Dispatch(t *testing.T) {
+	var called, args string
+
+	handler := func(name string) Handler {
+		return func(msg *tgbotapi.Message, a string) error {
+			called, args = name, a
+			return nil
+		}
+	}
+
+	r := NewRouter(func(id int64) bool { return id == authorizedChat }, handler("unauthorized"))
+	r.Register(Command{Name: "help", Handler: handler("help")})
+	r.Register(Command{Name: "forecast", RequiresAuth: true, Handler: handler("forecast")})
+	r.Register(Command{Name: "stats", AdminOnly: true, Handler: handler("stats")})
+	r.Admins(func(id int64) bool { return id == adminChat })
+	r.Location(handler("location"))
+	r.Fallback(handler("text"))
+
+	located := func(chatID int64) *tgbotapi.Message {
+		msg := message(chatID, "")
+		msg.Location = &tgbotapi.Location{Latitude: 53.9, Longitude: 27.56}
+		return msg
+	}
+
+	var useCase = []struct {
+		Name    string
+		Chat    int64
+		Text    string
+		Called  string
+		Args    string
+		Located bool
+		IsError bool
+	}{
+		{Name: "Public command", Chat: 2, Text: "/help", Called: "help", Args: ""},
+		{Name: "Command with arguments", Chat: authorizedChat, Text: "/forecast  New York 5 ", Called: "forecast", Args: "New York 5"},
+		{Name: "Command addressed to the bot", Chat: authorizedChat, Text: "/forecast@weather_bot Minsk", Called: "forecast", Args: "Minsk"},
+		{Name: "Auth required", Chat: 2, Text: "/forecast Minsk", Called: "unauthorized", Args: ""},
+		{Name: "Plain text", Chat: 2, Text: " Minsk ", Called: "text", Args: "Minsk"},
+		{Name: "Unknown command", Chat: authorizedChat, Text: "/nope", IsError: true},
+		{Name: "Admin command", Chat: adminChat, Text: "/stats", Called: "stats"},
+		{Name: "Admin command from others", Chat: authorizedChat, Text: "/stats", IsError: true},
+		{Name: "Location", Chat: authorizedChat, Located: true, Called: "location"},
+		{Name: "Location needs auth", Chat: 2, Located: true, Called: "unauthorized"},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			called, args = "", ""
+			msg := message(us.Chat, us.Text)
+			if us.Located {
+				msg = located(us.Chat)
+			}
+			err := r.Dispatch(msg)
+			if us.IsError {
+				assert.ErrorAs(t, err, new(ErrUnknownCommand))
+				assert.Empty(t, called)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, us.Called, called)
+				assert.Equal(t, us.Args, args)
+			}
+		})
+	}
+}
+
+func TestRouter_DispatchGroup(t *testing.T) {
+	var called, args string
+
+	handler := func(name string) Handler {
+		return func(msg *tgbotapi.Message, a string) error {
+			called, args = name, a
+			return nil
+		}
+	}
+
+	r := NewRouter(func(id int64) bool { return id == authorizedChat }, handler("unauthorized"))
+	r.Register(Command{Name: "help", Handler: handler("help")})
+	r.Register(Command{Name: "forecast", RequiresAuth: true, Handler: handler("forecast")})
+	r.Register(Command{Name: "login", PrivateOnly: true, Handler: handler("login")})
+	r.Groups("weather_bot", handler("private only"))
+	r.Location(handler("location"))
+	r.Fallback(handler("text"))
+
+	var useCase = []struct {
+		Name    string
+		From    int64
+		Text    string
+		ReplyTo string
+		Located bool
+		Called  string
+		Args    string
+		IsError bool
+	}{
+		{Name: "Plain text is ignored", From: authorizedChat, Text: "Minsk"},
+		{Name: "Mention", From: authorizedChat, Text: "@weather_bot Minsk", Called: "text", Args: "Minsk"},
+		{Name: "Mention after the city", From: authorizedChat, Text: "New York @Weather_Bot", Called: "text", Args: "New York"},
+		{Name: "Mention after text that grows in lower case", From: authorizedChat,
+			Text: "ȺȺȺȺȺȺȺȺȺȺȺȺȺȺȺȺ @weather_bot", Called: "text", Args: "ȺȺȺȺȺȺȺȺȺȺȺȺȺȺȺȺ"},
+		{Name: "Mention after text that shrinks in lower case", From: authorizedChat,
+			Text: "İstanbul @weather_bot", Called: "text", Args: "İstanbul"},
+		{Name: "Mention after an emoji", From: authorizedChat, Text: "🌧 @weather_bot Minsk", Called: "text", Args: "🌧 Minsk"},
+		{Name: "Mention of another bot", From: authorizedChat, Text: "@weather_bot2 Minsk"},
+		{Name: "Reply to the bot", From: authorizedChat, Text: "Brest", ReplyTo: "weather_bot", Called: "text", Args: "Brest"},
+		{Name: "Reply to someone else", From: authorizedChat, Text: "Brest", ReplyTo: "alice"},
+		{Name: "Command", From: 2, Text: "/help", Called: "help"},
+		{Name: "Command addressed to the bot", From: authorizedChat, Text: "/forecast@weather_bot Minsk", Called: "forecast", Args: "Minsk"},
+		{Name: "Command for another bot", From: authorizedChat, Text: "/forecast@other_bot Minsk"},
+		{Name: "Sender is authorized", From: authorizedChat, Text: "/forecast Minsk", Called: "forecast", Args: "Minsk"},
+		{Name: "Sender is not authorized", From: 2, Text: "/forecast Minsk", Called: "unauthorized"},
+		{Name: "Private only command", From: authorizedChat, Text: "/login", Called: "private only"},
+		{Name: "Unknown command is ignored", From: authorizedChat, Text: "/nope"},
+		{Name: "Unknown command addressed to the bot", From: authorizedChat, Text: "/nope@weather_bot", IsError: true},
+		{Name: "Location is ignored", From: authorizedChat, Located: true},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			called, args = "", ""
+			msg := message(-100, us.Text)
+			msg.Chat.Type = "supergroup"
+			msg.From = &tgbotapi.User{ID: us.From}
+			if us.ReplyTo != "" {
+				msg.ReplyToMessage = &tgbotapi.Message{From: &tgbotapi.User{UserName: us.ReplyTo}}
+			}
+			if us.Located {
+				msg.Location = &tgbotapi.Location{Latitude: 53.9, Longitude: 27.56}
+			}
+
+			err := r.Dispatch(msg)
+			if us.IsError {
+				assert.ErrorAs(t, err, new(ErrUnknownCommand))
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, us.Called, called)
+			assert.Equal(t, us.Args, args)
+		})
+	}
+}
+
+func TestRouter_DispatchCallback(t *testing.T) {
+	var called, data string
+
+	handler := func(name string) CallbackHandler {
+		return func(cb *tgbotapi.CallbackQuery, d string) error {
+			called, data = name, d
+			return nil
+		}
+	}
+
+	r := NewRouter(func(id int64) bool { return id == authorizedChat }, func(msg *tgbotapi.Message, _ string) error {
+		called, data = "unauthorized", ""
+		return nil
+	})
+	r.RegisterCallback(Callback{Action: "w", RequiresAuth: true, Handler: handler("weather")})
+	r.RegisterCallback(Callback{Action: "about", Handler: handler("about")})
+
+	var useCase = []struct {
+		Name    string
+		Chat    int64
+		Data    string
+		Called  string
+		Args    string
+		IsError bool
+	}{
+		{Name: "Action with data", Chat: authorizedChat, Data: "w:m||New York", Called: "weather", Args: "m||New York"},
+		{Name: "Auth required", Chat: 2, Data: "w:m||Minsk", Called: "unauthorized"},
+		{Name: "Public action", Chat: 2, Data: "about", Called: "about"},
+		{Name: "Unknown action", Chat: authorizedChat, Data: "x:1", IsError: true},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			called, data = "", ""
+			err := r.DispatchCallback(&tgbotapi.CallbackQuery{Data: us.Data, Message: message(us.Chat, "")})
+			if us.IsError {
+				assert.ErrorAs(t, err, new(ErrUnknownCallback))
+				assert.Empty(t, called)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, us.Called, called)
+				assert.Equal(t, us.Args, data)
+			}
+		})
+	}
+
+	assert.Panics(t, func() { r.RegisterCallback(Callback{Action: "w"}) })
+}
+
+func TestRouter_Register(t *testing.T) {
+	r := NewRouter(nil, nil)
+	r.Register(Command{Name: "weather", Usage: "/weather <city>", Description: "Current weather", RequiresAuth: true})
+	r.Register(Command{Name: "help", Usage: "/help", Description: "Show help"})
+	r.Register(Command{Name: "kick", Usage: "/kick <chat>", Description: "End a chat's session", AdminOnly: true})
+	r.Register(Command{Name: "login", Usage: "/login", Description: "Log in", PrivateOnly: true})
+
+	assert.Equal(t, []tgbotapi.BotCommand{
+		{Command: "weather", Description: "Current weather"},
+		{Command: "help", Description: "Show help"},
+		{Command: "login", Description: "Log in"},
+	}, r.BotCommands())
+	assert.Equal(t, []tgbotapi.BotCommand{
+		{Command: "weather", Description: "Current weather"},
+		{Command: "help", Description: "Show help"},
+	}, r.GroupBotCommands())
+	assert.Equal(t, "Available commands:\n/weather <city> - Current weather (login required)\n/help - Show help\n/login - Log in",
+		r.Help())
+	assert.Equal(t, "Admin commands:\n/kick <chat> - End a chat's session", r.AdminHelp())
+	assert.Panics(t, func() { r.Register(Command{Name: "help"}) })
+}
+
+func TestParseForecastArgs(t *testing.T) {
+	var useCase = []struct {
+		Name    string
+		Args    string
+		Home    string
+		City    string
+		Days    int
+		IsError bool
+	}{
+		{Name: "City only", Args: "Minsk", City: "Minsk", Days: defaultForecastDays},
+		{Name: "Home city", Args: "", Home: "Minsk", City: "Minsk", Days: defaultForecastDays},
+		{Name: "Home city and days", Args: "5", Home: "Minsk", City: "Minsk", Days: 5},
+		{Name: "City over home city", Args: "Brest 2", Home: "Minsk", City: "Brest", Days: 2},
+		{Name: "City and days", Args: "Minsk 5", City: "Minsk", Days: 5},
+		{Name: "City with spaces", Args: "New York 2", City: "New York", Days: 2},
+		{Name: "Numeric city name", Args: "42", City: "42", Days: defaultForecastDays},
+		{Name: "Too many days", Args: "Minsk 10", IsError: true},
+		{Name: "No city", Args: " ", IsError: true},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			city, days, err := parseForecastArgs(us.Args, us.Home)
+			if us.IsError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, us.City, city)
+				assert.Equal(t, us.Days, days)
+			}
+		})
+	}
+}
+
+func TestParseAlertArgs(t *testing.T) {
+	var useCase = []struct {
+		Name      string
+		Args      string
+		Kind      alert.Kind
+		City      string
+		Threshold float64
+		IsError   bool
+	}{
+		{Name: "Default threshold", Args: "frost Minsk", Kind: alert.KindFrost, City: "Minsk", Threshold: 0},
+		{Name: "Negative threshold", Args: "Frost Minsk -5", Kind: alert.KindFrost, City: "Minsk", Threshold: -5},
+		{Name: "City with spaces", Args: "wind New York 20", Kind: alert.KindWind, City: "New York", Threshold: 20},
+		{Name: "Unknown kind", Args: "hail Minsk", IsError: true},
+		{Name: "No city", Args: "rain", IsError: true},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			kind, city, threshold, err := parseAlertArgs(us.Args, true)
+			if us.IsError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, us.Kind, kind)
+				assert.Equal(t, us.City, city)
+				assert.Equal(t, us.Threshold, threshold)
+			}
+		})
+	}
+}
@@ -0,0 +1,505 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	userpb "telegram_service/cmd/user/pb"
+	weatherpb "telegram_service/cmd/weather/pb"
+	"telegram_service/internal/alert"
+	"telegram_service/internal/chats"
+	"telegram_service/internal/client"
+	"telegram_service/internal/config"
+	"telegram_service/internal/dialog"
+	"telegram_service/internal/prefs"
+	"telegram_service/internal/scheduler"
+	"telegram_service/internal/service"
+	"telegram_service/internal/session"
+	"telegram_service/internal/telegramtest"
+	"testing"
+	"time"
+)
+
+const (
+	scenarioChat = 555
+	waitReply    = 5 * time.Second
+)
+
+// fakeWeather knows the weather in Minsk, and in Springfield when asked by
+// coordinates. A scenario holds mu to keep the calls waiting.
+type fakeWeather struct {
+	weatherpb.UnimplementedGetWeatherServer
+	mu       sync.Mutex
+	calls    int32
+	waiting  int32
+	geocodes int32
+}
+
+func (w *fakeWeather) Get(_ context.Context, req *weatherpb.Request) (*weatherpb.Response, error) {
+	atomic.AddInt32(&w.waiting, 1)
+	w.mu.Lock()
+	w.mu.Unlock()
+
+	n := atomic.AddInt32(&w.calls, 1)
+	if req.GetCoord() != nil && req.GetCity() == "Springfield" {
+		return &weatherpb.Response{Response: fmt.Sprintf("Springfield: 20°C, update %d", n), City: "Springfield"}, nil
+	}
+	if !strings.EqualFold(req.GetCity(), "Minsk") {
+		return nil, status.Error(codes.NotFound, "city not found")
+	}
+	return &weatherpb.Response{Response: fmt.Sprintf("Minsk: 12°C, update %d", n), City: "Minsk", Temp: 12}, nil
+}
+
+// Forecast only knows the time zones, which /home needs.
+func (w *fakeWeather) Forecast(_ context.Context, req *weatherpb.ForecastRequest) (*weatherpb.ForecastResponse, error) {
+	switch req.GetCity() {
+	case "Minsk":
+		return &weatherpb.ForecastResponse{City: "Minsk", Timezone: "Europe/Minsk"}, nil
+	case "Springfield":
+		return &weatherpb.ForecastResponse{City: "Springfield", Timezone: "America/Chicago"}, nil
+	}
+	return nil, status.Error(codes.NotFound, "city not found")
+}
+
+// Geocode finds one Minsk and two Springfields.
+func (w *fakeWeather) Geocode(_ context.Context, req *weatherpb.GeocodeRequest) (*weatherpb.GeocodeResponse, error) {
+	atomic.AddInt32(&w.geocodes, 1)
+	switch strings.ToLower(req.GetCity()) {
+	case "minsk":
+		return &weatherpb.GeocodeResponse{Places: []*weatherpb.Place{
+			{Name: "Minsk", Country: "BY", Coord: &weatherpb.Coordinates{Lat: 53.9, Lon: 27.5667}},
+		}}, nil
+	case "springfield":
+		return &weatherpb.GeocodeResponse{Places: []*weatherpb.Place{
+			{Name: "Springfield", State: "Illinois", Country: "US", Coord: &weatherpb.Coordinates{Lat: 39.8, Lon: -89.65}},
+			{Name: "Springfield", State: "Missouri", Country: "US", Coord: &weatherpb.Coordinates{Lat: 37.2, Lon: -93.3}},
+		}}, nil
+	}
+	return &weatherpb.GeocodeResponse{}, nil
+}
+
+// fakeUsers lets in "alice" with password "secret123", and is unavailable
+// for "bob".
+type fakeUsers struct {
+	userpb.UnimplementedUserServiceServer
+}
+
+func (fakeUsers) Login(_ context.Context, req *userpb.Request) (*userpb.LoginResponse, error) {
+	if req.GetLogin() == "bob" {
+		return nil, status.Error(codes.Unavailable, "connection refused")
+	}
+	if req.GetLogin() != "alice" || req.GetPassword() != "secret123" {
+		return nil, status.Error(codes.Unauthenticated, "wrong login or password")
+	}
+	return &userpb.LoginResponse{
+		Token:     "token",
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		User:      &userpb.User{Id: "1", Name: "Alice", Login: "alice"},
+	}, nil
+}
+
+// serveGRPC serves the registered services on a free port until the test ends.
+func serveGRPC(t *testing.T, register func(s *grpc.Server)) config.Upstream {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := grpc.NewServer()
+	register(s)
+	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	return config.Upstream{Address: lis.Addr().String(), Timeout: time.Second, MaxAttempts: 1, Keepalive: 5 * time.Minute}
+}
+
+// scenario is the bot wired to a fake Telegram and fake upstreams, talked to
+// from one chat.
+type scenario struct {
+	t       *testing.T
+	tg      *telegramtest.Server
+	weather *fakeWeather
+	stop    context.CancelFunc
+	err     chan error
+}
+
+// newScenario starts the bot with limits the scenarios do not hit, changed
+// by configure when it is not nil.
+func newScenario(t *testing.T, configure func(cfg *config.Config)) *scenario {
+	dir := t.TempDir()
+	weather := &fakeWeather{}
+	cfg := &config.Config{
+		Token:     "test",
+		Mode:      config.ModePolling,
+		Workers:   config.Workers{Count: 2, QueueDepth: 8},
+		Weather:   serveGRPC(t, func(s *grpc.Server) { weatherpb.RegisterGetWeatherServer(s, weather) }),
+		User:      serveGRPC(t, func(s *grpc.Server) { userpb.RegisterUserServiceServer(s, fakeUsers{}) }),
+		Scheduler: config.Scheduler{Interval: time.Hour},
+		Alerts:    config.Alerts{Interval: time.Hour},
+		Inline:    config.Inline{CacheTTL: time.Minute, CacheSize: 10},
+		Limits:    config.Limits{Rate: 100, Burst: 100, LoginAttempts: 5, Lockout: time.Minute, MaxLockout: time.Hour},
+		Admin:     config.Admin{BroadcastRate: 100},
+	}
+	if configure != nil {
+		configure(cfg)
+	}
+
+	tg := telegramtest.NewServer()
+	t.Cleanup(tg.Close)
+	cfg.Endpoint = tg.Endpoint()
+
+	weatherConn, err := client.Dial(cfg.Weather)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = weatherConn.Close() })
+	userConn, err := client.Dial(cfg.User)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = userConn.Close() })
+
+	users := userpb.NewUserServiceClient(userConn)
+	sessions := session.NewMemoryStore(time.Hour)
+	subscriptions, err := scheduler.NewStore(filepath.Join(dir, "subscriptions.json"))
+	require.NoError(t, err)
+	alerts, err := alert.NewStore(filepath.Join(dir, "alerts.json"))
+	require.NoError(t, err)
+	preferences, err := prefs.NewStore(filepath.Join(dir, "preferences.json"))
+	require.NoError(t, err)
+	known, err := chats.NewStore(filepath.Join(dir, "chats.json"))
+	require.NoError(t, err)
+
+	bot := NewTelegram(cfg,
+		service.NewTgService(weatherpb.NewGetWeatherClient(weatherConn), cfg.Weather.Timeout),
+		service.NewAuthService(sessions, users, cfg.User.Timeout),
+		service.NewFavoritesService(users, cfg.User.Timeout),
+		service.NewHealthService(weatherConn, userConn, cfg.Weather.Timeout),
+		dialog.NewManager(sessions), known, subscriptions, alerts, preferences)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &scenario{t: t, tg: tg, weather: weather, stop: cancel, err: make(chan error, 1)}
+	go func() { s.err <- bot.Start(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case err := <-s.err:
+			assert.NoError(t, err)
+		case <-time.After(waitReply):
+			t.Error("the bot did not stop")
+		}
+	})
+	return s
+}
+
+// say sends text from the chat and returns the ID of the message.
+func (s *scenario) say(text string) int {
+	return s.tg.SendMessage(scenarioChat, text)
+}
+
+// expect waits for the next call of the bot and checks that it is in the
+// scenario's chat.
+func (s *scenario) expect(method, prefix string) telegramtest.Call {
+	s.t.Helper()
+
+	return s.expectIn(scenarioChat, method, prefix)
+}
+
+// expectIn waits for the next call of the bot and checks its chat, its method
+// and that its text starts with prefix.
+func (s *scenario) expectIn(chatID int64, method, prefix string) telegramtest.Call {
+	s.t.Helper()
+
+	c, err := s.tg.Next(waitReply)
+	require.NoError(s.t, err, "waiting for %s %q", method, prefix)
+	require.Equal(s.t, method, c.Method, "call %+v", c)
+	assert.Equal(s.t, chatID, c.ChatID)
+	assert.True(s.t, strings.HasPrefix(c.Text, prefix), "text %q does not start with %q", c.Text, prefix)
+	return c
+}
+
+func (s *scenario) login() {
+	s.say("/login")
+	s.expect("sendMessage", askLogin)
+	s.say("alice")
+	s.expect("sendMessage", askPassword)
+	password := s.say("secret123")
+	deleted := s.expect("deleteMessage", "")
+	assert.Equal(s.t, password, deleted.MessageID)
+	s.expect("sendMessage", loginSuccess)
+}
+
+func TestScenario_Login(t *testing.T) {
+	s := newScenario(t, nil)
+
+	s.say("/weather Minsk")
+	s.expect("sendMessage", loginRequired)
+
+	s.login()
+
+	s.say("/weather Minsk")
+	s.expect("sendMessage", "Minsk: 12°C")
+}
+
+func TestScenario_Weather(t *testing.T) {
+	s := newScenario(t, nil)
+	s.login()
+
+	s.say("/weather Minsk")
+	reply := s.expect("sendMessage", "Minsk: 12°C, update 1")
+	refresh, ok := reply.Buttons()["🔄 Refresh"]
+	require.True(t, ok, "buttons %v", reply.Buttons())
+
+	s.tg.Press(scenarioChat, reply.MessageID, refresh)
+	edited := s.expect("editMessageText", "Minsk: 12°C, update 2")
+	assert.Equal(t, reply.MessageID, edited.MessageID)
+	s.expect("answerCallbackQuery", "")
+
+	s.say("Minsk")
+	s.expect("sendMessage", "Minsk: 12°C, update 3")
+}
+
+func TestScenario_Home(t *testing.T) {
+	s := newScenario(t, nil)
+	s.login()
+
+	s.say("/weather springfield")
+	s.expect("sendMessage", "There are several places called springfield")
+
+	s.say("/home springfield")
+	s.expect("sendMessage", "Your home city is Springfield now, time zone America/Chicago")
+	geocodes := atomic.LoadInt32(&s.weather.geocodes)
+
+	s.say("/weather")
+	s.expect("sendMessage", "Springfield: 20°C")
+	s.say("/weather")
+	s.expect("sendMessage", "Springfield: 20°C")
+	assert.Equal(t, geocodes, atomic.LoadInt32(&s.weather.geocodes), "the home city is not looked up again")
+}
+
+func TestScenario_Shutdown(t *testing.T) {
+	s := newScenario(t, func(cfg *config.Config) {
+		cfg.Workers = config.Workers{Count: 1, QueueDepth: 4}
+	})
+	s.login()
+
+	// The first update holds the only worker and the next ones wait in the
+	// chat's queue when the bot is told to stop, in the middle of a long poll.
+	s.weather.mu.Lock()
+	for i := 0; i < 4; i++ {
+		s.say("/weather Minsk")
+	}
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&s.weather.waiting) == 1 && s.tg.Pending() == 0
+	}, waitReply, time.Millisecond)
+	s.stop()
+	s.weather.mu.Unlock()
+
+	for i := 1; i <= 4; i++ {
+		s.expect("sendMessage", fmt.Sprintf("Minsk: 12°C, update %d", i))
+	}
+	select {
+	case err := <-s.err:
+		assert.NoError(t, err)
+		s.err <- err
+	case <-time.After(waitReply):
+		t.Error("the bot did not stop")
+	}
+}
+
+func TestScenario_BroadcastShutdown(t *testing.T) {
+	s := newScenario(t, func(cfg *config.Config) {
+		cfg.Admin = config.Admin{IDs: []int64{scenarioChat}, BroadcastRate: 0.1}
+	})
+
+	s.say("/help")
+	s.expect("sendMessage", "Available commands")
+
+	// The first message would go out in ten seconds, long after the bot stops.
+	s.say("/broadcast Maintenance at 22:00")
+	s.expect("sendMessage", "Broadcasting to 1 chats")
+	s.stop()
+
+	s.expect("sendMessage", "Broadcast stopped by shutdown: 0 sent, 0 failed, 1 not reached")
+	select {
+	case err := <-s.err:
+		assert.NoError(t, err)
+		s.err <- err
+	case <-time.After(waitReply):
+		t.Error("the bot did not stop")
+	}
+}
+
+func TestScenario_BadInput(t *testing.T) {
+	s := newScenario(t, nil)
+
+	s.say("/login")
+	s.expect("sendMessage", askLogin)
+	s.say("alice")
+	s.expect("sendMessage", askPassword)
+	s.say("wrong")
+	s.expect("deleteMessage", "")
+	s.expect("sendMessage", loginRequired)
+
+	s.login()
+
+	var useCase = []struct {
+		Name  string
+		Text  string
+		Reply string
+	}{
+		{Name: "Unknown city", Text: "/weather Atlantis", Reply: "Incorrect input"},
+		{Name: "Too many days", Text: "/forecast Minsk 10", Reply: "Incorrect input: "},
+		{Name: "Unknown command", Text: "/nope", Reply: "Unknown command"},
+		{Name: "Unknown time zone", Text: "/timezone Mars/Olympus", Reply: "Incorrect input: unknown time zone"},
+		{Name: "Nothing to cancel", Text: "/cancel", Reply: "There is nothing to cancel"},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			s.t = t
+			s.say(us.Text)
+			s.expect("sendMessage", us.Reply)
+		})
+	}
+}
+
+func TestScenario_Limits(t *testing.T) {
+	s := newScenario(t, func(cfg *config.Config) {
+		cfg.Limits.Rate, cfg.Limits.Burst, cfg.Limits.LoginAttempts = 0.01, 9, 2
+	})
+
+	// Neither a city nor an outage is a failed attempt.
+	for i := 0; i < 2; i++ {
+		s.say("Minsk")
+		s.expect("sendMessage", loginRequired)
+		s.say("bob secret123")
+		s.expect("deleteMessage", "")
+		s.expect("sendMessage", cannotLogin)
+	}
+
+	s.say("alice wrong")
+	s.expect("deleteMessage", "")
+	s.expect("sendMessage", loginRequired)
+	s.say("alice wrong")
+	s.expect("deleteMessage", "")
+	s.expect("sendMessage", "Too many failed login attempts. Try again in 60 seconds")
+
+	password := s.say("alice secret123")
+	deleted := s.expect("deleteMessage", "")
+	assert.Equal(t, password, deleted.MessageID, "the password is deleted while locked out")
+	s.expect("sendMessage", "Too many failed login attempts")
+
+	for i := 0; i < 5; i++ {
+		s.say("/help")
+	}
+	s.expect("sendMessage", "Available commands")
+	s.expect("sendMessage", "Available commands")
+	s.expect("sendMessage", "You are sending messages too fast. Please wait")
+
+	_, err := s.tg.Next(300 * time.Millisecond)
+	assert.Error(t, err, "the chat is warned once")
+
+	password = s.say("alice secret123")
+	deleted = s.expect("deleteMessage", "")
+	assert.Equal(t, password, deleted.MessageID, "the password is deleted while throttled")
+	s.expect("sendMessage", "Too many failed login attempts")
+}
+
+func TestScenario_AccountLockout(t *testing.T) {
+	s := newScenario(t, func(cfg *config.Config) { cfg.Limits.LoginAttempts = 2 })
+
+	s.tg.SendMessage(601, "alice wrong")
+	s.expectIn(601, "deleteMessage", "")
+	s.expectIn(601, "sendMessage", loginRequired)
+	s.tg.SendMessage(602, "Alice guess")
+	s.expectIn(602, "deleteMessage", "")
+	s.expectIn(602, "sendMessage", "Too many failed login attempts")
+
+	s.tg.SendMessage(603, "alice secret123")
+	s.expectIn(603, "deleteMessage", "")
+	s.expectIn(603, "sendMessage", "Too many failed login attempts")
+}
+
+func TestScenario_Admin(t *testing.T) {
+	const user = 777
+	s := newScenario(t, func(cfg *config.Config) { cfg.Admin.IDs = []int64{scenarioChat} })
+
+	s.tg.SendMessage(user, "alice secret123")
+	s.expectIn(user, "deleteMessage", "")
+	s.expectIn(user, "sendMessage", loginSuccess)
+	s.tg.SendMessage(user, "/stats")
+	s.expectIn(user, "sendMessage", "Unknown command")
+
+	s.say("/help")
+	help := s.expect("sendMessage", "Available commands")
+	assert.Contains(t, help.Text, "Admin commands:\n/stats")
+
+	s.say("/stats")
+	stats := s.expect("sendMessage", "Uptime: ")
+	assert.Contains(t, stats.Text, "Active sessions: 1\nKnown chats: 2\nLast hour: 3 messages, 0 errors")
+
+	s.say("/health")
+	health := s.expect("sendMessage", "✅ weather: SERVING")
+	assert.Contains(t, health.Text, "✅ user: SERVING")
+	assert.Contains(t, health.Text, "✅ telegram: OK")
+
+	s.say("/broadcast Maintenance at 22:00")
+	var got []string
+	for i := 0; i < 4; i++ {
+		c, err := s.tg.Next(waitReply)
+		require.NoError(t, err)
+		got = append(got, fmt.Sprintf("%d %s", c.ChatID, c.Text))
+	}
+	assert.ElementsMatch(t, []string{
+		"555 Broadcasting to 2 chats",
+		"777 Maintenance at 22:00",
+		"555 Maintenance at 22:00",
+		"555 Broadcast done: 2 sent, 0 failed",
+	}, got)
+
+	s.say("/kick 777")
+	s.expectIn(user, "sendMessage", "An administrator ended your session")
+	s.expect("sendMessage", "Chat 777 is logged out")
+	s.say("/kick 777")
+	s.expect("sendMessage", "Chat 777 is not logged in")
+
+	s.tg.SendMessage(user, "/weather Minsk")
+	s.expectIn(user, "sendMessage", loginRequired)
+
+	s.tg.SendFrom(-100, scenarioChat, "/help")
+	help = s.expectIn(-100, "sendMessage", "Available commands")
+	assert.Contains(t, help.Text, "Admin commands:\n/stats", "admins are told apart by the sender in groups")
+}
+
+func TestScenario_Group(t *testing.T) {
+	const group = -100
+	s := newScenario(t, nil)
+	s.login()
+
+	s.tg.SendFrom(group, scenarioChat, "Minsk")
+	s.tg.SendFrom(group, scenarioChat, "@weather_test_bot Minsk")
+	s.expectIn(group, "sendMessage", "Minsk: 12°C")
+
+	s.tg.SendFrom(group, scenarioChat, "/login")
+	s.expectIn(group, "sendMessage", "This works only in a private chat with me")
+
+	s.tg.SendFrom(group, 777, "/weather Minsk")
+	s.expectIn(group, "sendMessage", "First of all, log in in a private chat with me")
+
+	s.tg.SendFrom(group, scenarioChat, "/home Minsk")
+	s.expectIn(group, "sendMessage", "Only group admins can set the group's city")
+
+	s.tg.Promote(group, scenarioChat)
+	s.tg.SendFrom(group, scenarioChat, "/home Minsk")
+	s.expectIn(group, "sendMessage", "The group's city is Minsk now")
+
+	s.tg.SendFrom(group, scenarioChat, "/weather@weather_test_bot")
+	s.expectIn(group, "sendMessage", "Minsk: 12°C")
+}
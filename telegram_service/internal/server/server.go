@@ -1,77 +1,235 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"log"
+	"sync"
+	"telegram_service/internal/alert"
+	"telegram_service/internal/cache"
+	"telegram_service/internal/chats"
 	"telegram_service/internal/config"
+	"telegram_service/internal/dialog"
+	"telegram_service/internal/prefs"
+	"telegram_service/internal/ratelimit"
+	"telegram_service/internal/scheduler"
 	"telegram_service/internal/service"
+	"telegram_service/internal/stats"
+	"telegram_service/internal/worker"
+	"unicode/utf8"
 )
 
 type Telegram struct {
-	cfg         *config.Config
-	tgService   *service.TgService
-	authService *service.AuthService
+	cfg             *config.Config
+	tgService       *service.TgService
+	authService     *service.AuthService
+	favoriteService *service.FavoritesService
+	healthService   *service.HealthService
+	subscriptions   *scheduler.Store
+	alerts          *alert.Store
+	prefs           *prefs.Store
+	bot             *tgbotapi.BotAPI
+	router          *Router
+	dialogs         *dialog.Manager
+	recent          *recentCities
+	inlineCache     *cache.Cache[inlineWeather]
+	limiter         *ratelimit.Limiter
+	logins          *ratelimit.Lockout[int64]
+	accounts        *ratelimit.Lockout[string]
+	chats           *chats.Store
+	stats           *stats.Counter
+	broadcasting    int32
+	// ctx lives as long as the bot, background work started by handlers
+	// stops with it.
+	ctx context.Context
+	// background counts that work, Start waits for it before returning.
+	background *sync.WaitGroup
 }
 
-func NewTelegram(cfg *config.Config, tgService *service.TgService, auth *service.AuthService) Telegram {
+func NewTelegram(cfg *config.Config, tgService *service.TgService, auth *service.AuthService,
+	favorites *service.FavoritesService, health *service.HealthService, dialogs *dialog.Manager, known *chats.Store,
+	subscriptions *scheduler.Store, alerts *alert.Store, preferences *prefs.Store) Telegram {
 	return Telegram{
-		cfg:         cfg,
-		tgService:   tgService,
-		authService: auth,
+		cfg:             cfg,
+		tgService:       tgService,
+		authService:     auth,
+		favoriteService: favorites,
+		healthService:   health,
+		subscriptions:   subscriptions,
+		alerts:          alerts,
+		prefs:           preferences,
+		dialogs:         dialogs,
+		recent:          newRecentCities(),
+		inlineCache:     cache.New[inlineWeather](cfg.Inline.CacheTTL, cfg.Inline.CacheSize),
+		limiter:         ratelimit.NewLimiter(cfg.Limits.Rate, cfg.Limits.Burst),
+		logins:          ratelimit.NewLockout[int64](cfg.Limits.LoginAttempts, cfg.Limits.Lockout, cfg.Limits.MaxLockout),
+		accounts:        ratelimit.NewLockout[string](cfg.Limits.LoginAttempts, cfg.Limits.Lockout, cfg.Limits.MaxLockout),
+		chats:           known,
+		ctx:             context.Background(),
+		background:      &sync.WaitGroup{},
+		stats:           stats.NewCounter(),
 	}
 }
 
-func (t *Telegram) Start() {
-	bot, err := tgbotapi.NewBotAPI(t.cfg.Token)
+// Start receives updates the way cfg.Mode says until ctx is done.
+func (t *Telegram) Start(ctx context.Context) error {
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(t.cfg.Token, t.cfg.Endpoint)
 	if err != nil {
-		log.Panic(err)
+		return fmt.Errorf("failed to connect to Telegram: %w", err)
 	}
 
 	bot.Debug = false
+	t.bot = bot
+	t.ctx = ctx
+	defer t.background.Wait()
+	t.router = t.newRouter()
+	t.registerDialogs()
 
 	log.Printf("Authorized on account %s", bot.Self.UserName)
 
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
+	_, err = bot.Request(tgbotapi.NewSetMyCommands(t.router.BotCommands()...))
+	if err == nil {
+		_, err = bot.Request(tgbotapi.NewSetMyCommandsWithScope(tgbotapi.NewBotCommandScopeAllGroupChats(),
+			t.router.GroupBotCommands()...))
+	}
+	if err != nil {
+		log.Printf("Failed to publish commands: %v", err)
+	}
+
+	var updates tgbotapi.UpdatesChannel
+	if t.cfg.Mode == config.ModeWebhook {
+		updates, err = t.listenWebhook(ctx)
+	} else {
+		updates, err = t.poll(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	go scheduler.New(t.subscriptions, t.sendDigest, t.cfg.Scheduler.Interval, t.cfg.Scheduler.CatchUp).Start(ctx)
+
+	go alert.NewMonitor(t.alerts, t.tgService.Conditions, t.sendAlert, t.cfg.Alerts.Interval, t.cfg.Alerts.Cooldown).Start(ctx)
 
-	updates := bot.GetUpdatesChan(u)
+	if t.cfg.Metrics.Address != "" {
+		go t.serveMetrics(ctx)
+	}
+
+	pool := worker.NewPool(t.cfg.Workers.Count, t.cfg.Workers.QueueDepth)
+	defer pool.Close()
 
+	// Intake stops when ctx is done and closes updates, so the updates Telegram
+	// already counts as delivered are still handled before the pool closes.
 	for update := range updates {
-		if update.Message != nil {
-			log.Printf("[%s] %s", update.Message.From.UserName, update.Message.Text)
-
-			var message string
-			if t.authService.CheckAuth(update.Message.Chat.ID) {
-				message = t.GetWeather(update, err)
-			} else {
-				if t.authService.Auth(update.Message.Text, update.Message.Chat.ID) {
-					message = "You are successfully authorized. \nSelect the city where you want to know the weather. " +
-						"\nFor example: Minsk"
-				} else {
-					message = "First of all, you should log in.\nWrite correct login and password, please" +
-						"\n For example: name password"
-				}
-			}
-
-			msg := tgbotapi.NewMessage(update.Message.Chat.ID, message)
-			msg.ReplyToMessageID = update.Message.MessageID
-
-			bot.Send(msg)
-		}
+		t.submit(pool, update)
 	}
+	log.Printf("Stopped receiving updates, finishing queued ones")
+	return nil
 }
 
-func (t *Telegram) GetWeather(update tgbotapi.Update, err error) string {
-	var message string
+// submit queues an update behind the earlier ones of its chat, while the rate
+// limit counts every user on their own, also in groups.
+func (t *Telegram) submit(pool *worker.Pool, update tgbotapi.Update) {
+	var job func()
+	var chatID, userID int64
+	switch {
+	case update.Message != nil:
+		msg := update.Message
+		log.Printf("[%s] %s", msg.From.UserName, redacted(msg))
+		job, chatID, userID = func() { t.handleMessage(msg) }, msg.Chat.ID, sender(msg)
+	case update.CallbackQuery != nil:
+		cb := update.CallbackQuery
+		log.Printf("[%s] button %s", cb.From.UserName, cb.Data)
+		job, chatID, userID = func() { t.handleCallback(cb) }, cb.From.ID, cb.From.ID
+		if cb.Message != nil {
+			chatID = cb.Message.Chat.ID
+		}
+	case update.InlineQuery != nil:
+		q := update.InlineQuery
+		log.Printf("[%s] inline %s", q.From.UserName, q.Query)
+		job, chatID, userID = func() { t.handleInlineQuery(q) }, q.From.ID, q.From.ID
+	default:
+		return
+	}
 
-	if update.Message.Text != "" {
-		message, err = t.tgService.GetWeather(update.Message.Text)
-		if err != nil || message == "" {
-			message = "Incorrect input"
+	if r := t.limiter.Allow(userID); !r.Allowed && !t.mayHoldPassword(update) {
+		job = t.throttled(update, r)
+		if job == nil {
+			return
 		}
+	}
 
-	} else {
-		message = "Write city please"
+	// A chat with a full queue loses the update rather than holding up the
+	// others.
+	err := pool.Submit(chatID, job)
+	if err != nil {
+		log.Printf("Dropped update %d: %v", update.UpdateID, err)
+	}
+}
+
+// redacted describes a message for the log without its text, which may be a
+// password: /login takes it as arguments, and the login and register
+// dialogs as a plain message.
+func redacted(msg *tgbotapi.Message) string {
+	n := utf8.RuneCountInString(msg.Text)
+	if msg.IsCommand() {
+		return fmt.Sprintf("/%s, %d characters", msg.Command(), n)
+	}
+	return fmt.Sprintf("message, %d characters", n)
+}
+
+func (t *Telegram) handleMessage(msg *tgbotapi.Message) {
+	err := t.router.Dispatch(msg)
+	t.record(msg.Chat.ID, err)
+
+	var unknown ErrUnknownCommand
+	switch {
+	case err == nil:
+		return
+	case errors.As(err, &unknown):
+		err = t.reply(msg, "Unknown command. Send /help to see what I can do")
+	default:
+		log.Printf("Failed to handle message: %v", err)
+		err = t.reply(msg, "Something went wrong, please try again later")
+	}
+	if err != nil {
+		log.Printf("Failed to send reply: %v", err)
+	}
+}
+
+// handleCallback answers every button press, so the client stops showing a
+// spinner, and tells about failures in a toast.
+func (t *Telegram) handleCallback(cb *tgbotapi.CallbackQuery) {
+	var text string
+	err := t.router.DispatchCallback(cb)
+	t.stats.Record(err != nil)
+	if err != nil {
+		log.Printf("Failed to handle button: %v", err)
+		text = "Something went wrong, please try again later"
 	}
-	return message
+
+	_, err = t.bot.Request(tgbotapi.NewCallback(cb.ID, text))
+	if err != nil {
+		log.Printf("Failed to answer button: %v", err)
+	}
+}
+
+func (t *Telegram) reply(msg *tgbotapi.Message, text string) error {
+	m := tgbotapi.NewMessage(msg.Chat.ID, text)
+	m.ReplyToMessageID = msg.MessageID
+
+	_, err := t.bot.Send(m)
+	return err
+}
+
+// replyWithKeyboard replies with an inline or reply keyboard. A nil markup
+// sends no keyboard.
+func (t *Telegram) replyWithKeyboard(msg *tgbotapi.Message, text string, markup interface{}) error {
+	m := tgbotapi.NewMessage(msg.Chat.ID, text)
+	m.ReplyToMessageID = msg.MessageID
+	m.ReplyMarkup = markup
+
+	_, err := t.bot.Send(m)
+	return err
 }
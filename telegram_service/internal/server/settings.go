@@ -0,0 +1,240 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"strings"
+	"telegram_service/internal/prefs"
+	"telegram_service/internal/service"
+	"telegram_service/internal/session"
+	"time"
+)
+
+// actionSettings buttons carry "<setting>=<value>".
+const actionSettings = "s"
+
+const (
+	settingUnits = "units"
+	settingLang  = "lang"
+	settingHome  = "home"
+)
+
+// query looks up the city in the chat's units and language.
+func (t *Telegram) query(chatID int64, city string) service.Query {
+	p := t.prefs.Get(chatID)
+	return service.Query{City: city, Units: p.Units, Lang: p.Language}
+}
+
+// homeQuery looks up the chat's home city at the place found when it was set.
+func (t *Telegram) homeQuery(chatID int64) service.Query {
+	p := t.prefs.Get(chatID)
+	return service.Query{City: p.HomeCity, Units: p.Units, Lang: p.Language, Coord: p.HomeCoord}
+}
+
+func (t *Telegram) settings(msg *tgbotapi.Message, _ string) error {
+	s, err := t.authService.Session(msg.Chat.ID)
+	if err != nil {
+		return t.sessionError(msg, err)
+	}
+
+	p := t.prefs.Get(msg.Chat.ID)
+	return t.replyWithKeyboard(msg, settingsText(p, s), settingsKeyboard(p, t.recent.list(msg.Chat.ID)))
+}
+
+// settingsCallback changes one setting and redraws the settings message.
+func (t *Telegram) settingsCallback(cb *tgbotapi.CallbackQuery, data string) error {
+	chatID := cb.Message.Chat.ID
+	key, value, err := parseSetting(data)
+	if err != nil {
+		return err
+	}
+
+	s, err := t.authService.Session(chatID)
+	if err != nil {
+		return t.sessionError(cb.Message, err)
+	}
+
+	var p prefs.Preferences
+	switch key {
+	case settingUnits:
+		p, err = t.prefs.Update(chatID, func(p *prefs.Preferences) { p.Units = value })
+	case settingLang:
+		p, err = t.prefs.Update(chatID, func(p *prefs.Preferences) { p.Language = value })
+	case settingHome:
+		p, err = t.setHome(chatID, chatID, value)
+	}
+	if err != nil {
+		return err
+	}
+	return t.edit(cb.Message, settingsText(p, s), settingsKeyboard(p, t.recent.list(chatID)))
+}
+
+// home sets the home city, which /weather, /forecast and /daily use when no
+// city is given. In groups it is the group's city, set by its admins.
+func (t *Telegram) home(msg *tgbotapi.Message, city string) error {
+	if isGroup(msg.Chat) {
+		return t.groupHome(msg, city)
+	}
+
+	if city == "" {
+		p := t.prefs.Get(msg.Chat.ID)
+		if p.HomeCity == "" {
+			return t.reply(msg, "You have no home city.\nFor example: /home Minsk")
+		}
+		return t.reply(msg, fmt.Sprintf("Your home city is %s (%s)", p.HomeCity, p.Timezone))
+	}
+
+	p, err := t.setHome(msg.Chat.ID, msg.Chat.ID, city)
+	if errors.Is(err, service.ErrNotLoggedIn) {
+		return t.sessionError(msg, err)
+	}
+	if err != nil {
+		return t.reply(msg, "Incorrect input: unknown city "+city)
+	}
+	return t.reply(msg, fmt.Sprintf("Your home city is %s now, time zone %s", p.HomeCity, p.Timezone))
+}
+
+func (t *Telegram) groupHome(msg *tgbotapi.Message, city string) error {
+	if city == "" {
+		home := t.prefs.Get(msg.Chat.ID).HomeCity
+		if home == "" {
+			return t.reply(msg, "The group has no city.\nGroup admins can set it, for example: /home Minsk")
+		}
+		return t.reply(msg, "The group's city is "+home)
+	}
+
+	member, err := t.bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: msg.Chat.ID, UserID: sender(msg)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get chat member: %w", err)
+	}
+	if !member.IsCreator() && !member.IsAdministrator() {
+		return t.reply(msg, "Only group admins can set the group's city")
+	}
+
+	p, err := t.setHome(msg.Chat.ID, sender(msg), city)
+	if errors.Is(err, service.ErrNotLoggedIn) {
+		return t.sessionError(msg, err)
+	}
+	if err != nil {
+		return t.reply(msg, "Incorrect input: unknown city "+city)
+	}
+	return t.reply(msg, fmt.Sprintf("The group's city is %s now. Ask /weather without a city to get it", p.HomeCity))
+}
+
+// setHome sets the chat's home city along with its place and time zone,
+// looked up on behalf of the user. Of several places with the name the best
+// match is kept, so /weather does not ask which one every time. Without
+// geocoding only the name is kept.
+func (t *Telegram) setHome(chatID, userID int64, city string) (prefs.Preferences, error) {
+	ctx, err := t.authService.Context(context.Background(), userID)
+	if err != nil {
+		return prefs.Preferences{}, err
+	}
+
+	var coord *service.Coord
+	places, err := t.tgService.Geocode(ctx, city)
+	if err == nil && len(places) > 0 {
+		city, coord = places[0].Name, &places[0].Coord
+	}
+
+	tz, err := t.tgService.Timezone(ctx, city)
+	if err != nil {
+		return prefs.Preferences{}, err
+	}
+
+	return t.prefs.Update(chatID, func(p *prefs.Preferences) {
+		p.HomeCity = city
+		p.HomeCoord = coord
+		p.Timezone = tz
+	})
+}
+
+// timezone overrides the time zone taken from the home city.
+func (t *Telegram) timezone(msg *tgbotapi.Message, name string) error {
+	if name == "" {
+		return t.reply(msg, "Your time zone is "+t.prefs.Get(msg.Chat.ID).Location().String()+
+			"\nFor example: /timezone Europe/Minsk")
+	}
+
+	_, err := time.LoadLocation(name)
+	if err != nil || strings.EqualFold(name, "local") {
+		return t.reply(msg, fmt.Sprintf("Incorrect input: unknown time zone %q\nFor example: /timezone Europe/Minsk", name))
+	}
+
+	_, err = t.prefs.Update(msg.Chat.ID, func(p *prefs.Preferences) { p.Timezone = name })
+	if err != nil {
+		return err
+	}
+	return t.reply(msg, "Your time zone is "+name+" now")
+}
+
+func settingsText(p prefs.Preferences, s session.Session) string {
+	home := p.HomeCity
+	if home == "" {
+		home = "not set, use /home <city>"
+	}
+
+	return fmt.Sprintf("Units: %s\nLanguage: %s\nHome city: %s\nTime zone: %s\n\n"+
+		"You are logged in as %s (%s).\nSession expires at %s.\nUse /logout to switch accounts",
+		p.Units, p.LanguageName(), home, p.Location(),
+		s.Name, s.Login, s.ExpiresAt.In(p.Location()).Format("15:04 02 Jan"))
+}
+
+// settingsKeyboard switches units and language, and offers the recent cities
+// as the home city.
+func settingsKeyboard(p prefs.Preferences, recent []string) *tgbotapi.InlineKeyboardMarkup {
+	units := []tgbotapi.InlineKeyboardButton{
+		settingButton("°C", settingUnits, service.UnitsMetric, p.Units == service.UnitsMetric),
+		settingButton("°F", settingUnits, service.UnitsImperial, p.Units == service.UnitsImperial),
+	}
+
+	var langs []tgbotapi.InlineKeyboardButton
+	for _, l := range prefs.Languages {
+		langs = append(langs, settingButton(l.Name, settingLang, l.Code, p.Language == l.Code))
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{units, langs}
+	for _, city := range recent {
+		data := actionSettings + ":" + settingHome + "=" + city
+		if strings.EqualFold(city, p.HomeCity) || len(data) > maxCallbackData {
+			continue
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🏠 "+city, data)))
+	}
+	return keyboard(rows...)
+}
+
+func settingButton(text, key, value string, selected bool) tgbotapi.InlineKeyboardButton {
+	if selected {
+		text = "✓ " + text
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(text, actionSettings+":"+key+"="+value)
+}
+
+// parseSetting splits "<setting>=<value>" and checks the value.
+func parseSetting(data string) (string, string, error) {
+	key, value, _ := strings.Cut(data, "=")
+	switch key {
+	case settingUnits:
+		if value == service.UnitsMetric || value == service.UnitsImperial {
+			return key, value, nil
+		}
+	case settingLang:
+		for _, l := range prefs.Languages {
+			if l.Code == value {
+				return key, value, nil
+			}
+		}
+	case settingHome:
+		if value != "" {
+			return key, value, nil
+		}
+	default:
+		return "", "", fmt.Errorf("unknown setting %q", data)
+	}
+	return "", "", fmt.Errorf("bad value of setting %q", data)
+}
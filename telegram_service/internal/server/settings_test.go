@@ -0,0 +1,53 @@
+package server
+
+import (
+	"github.com/stretchr/testify/assert"
+	"telegram_service/internal/prefs"
+	"telegram_service/internal/service"
+	"testing"
+)
+
+func TestParseSetting(t *testing.T) {
+	var useCase = []struct {
+		Name    string
+		Data    string
+		Key     string
+		Value   string
+		IsError bool
+	}{
+		{Name: "Units", Data: "units=imperial", Key: settingUnits, Value: service.UnitsImperial},
+		{Name: "Language", Data: "lang=ru", Key: settingLang, Value: "ru"},
+		{Name: "Home city", Data: "home=New York", Key: settingHome, Value: "New York"},
+		{Name: "Unknown units", Data: "units=kelvin", IsError: true},
+		{Name: "Unknown language", Data: "lang=xx", IsError: true},
+		{Name: "Empty home city", Data: "home=", IsError: true},
+		{Name: "Unknown setting", Data: "color=red", IsError: true},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			key, value, err := parseSetting(us.Data)
+			if us.IsError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, us.Key, key)
+				assert.Equal(t, us.Value, value)
+			}
+		})
+	}
+}
+
+func TestSettingsKeyboard(t *testing.T) {
+	p := prefs.Default()
+	p.HomeCity = "Minsk"
+
+	markup := settingsKeyboard(p, []string{"minsk", "Brest"})
+	assert.Len(t, markup.InlineKeyboard, 3, "the home city is not offered again")
+
+	units := markup.InlineKeyboard[0]
+	assert.Equal(t, "✓ °C", units[0].Text)
+	assert.Equal(t, "s:units=imperial", *units[1].CallbackData)
+	assert.Len(t, markup.InlineKeyboard[1], len(prefs.Languages))
+	assert.Equal(t, "s:home=Brest", *markup.InlineKeyboard[2][0].CallbackData)
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"log"
+	"net/http"
+	"time"
+)
+
+// secretHeader carries the secret token Telegram was given in setWebhook.
+const secretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+const shutdownTimeout = 10 * time.Second
+
+// webhookHandler accepts updates pushed by Telegram.
+type webhookHandler struct {
+	secret  string
+	updates chan<- tgbotapi.Update
+	done    <-chan struct{}
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(secretHeader)), []byte(h.secret)) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var update tgbotapi.Update
+	err := json.NewDecoder(r.Body).Decode(&update)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// Once shutdown starts nothing is accepted, even with room in the queue:
+	// select would pick a ready case at random.
+	select {
+	case <-h.done:
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	default:
+	}
+
+	select {
+	case h.updates <- update:
+		w.WriteHeader(http.StatusOK)
+	case <-h.done:
+		// Telegram retries the update, possibly on another replica.
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case <-r.Context().Done():
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+// listenWebhook registers the webhook with Telegram and serves it until ctx is
+// done. The returned channel is closed once the server has stopped. The
+// webhook stays registered on shutdown, since other replicas still serve it.
+func (t *Telegram) listenWebhook(ctx context.Context) (tgbotapi.UpdatesChannel, error) {
+	cfg := t.cfg.Webhook
+
+	params := tgbotapi.Params{"url": cfg.URL}
+	params.AddNonEmpty("secret_token", cfg.Secret)
+	_, err := t.bot.MakeRequest("setWebhook", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set webhook: %w", err)
+	}
+
+	updates := make(chan tgbotapi.Update, t.bot.Buffer)
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Path, &webhookHandler{secret: cfg.Secret, updates: updates, done: ctx.Done()})
+	srv := &http.Server{Addr: ":" + t.cfg.Port, Handler: mux}
+
+	failed := make(chan struct{})
+	go func() {
+		log.Printf("Listening for webhook on :%s%s", t.cfg.Port, cfg.Path)
+		err := srv.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Webhook server failed: %v", err)
+			close(failed)
+		}
+	}()
+
+	// Shutdown waits for the running handlers, so none of them sends on
+	// updates after it is closed.
+	go func() {
+		defer close(updates)
+
+		select {
+		case <-ctx.Done():
+		case <-failed:
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err := srv.Shutdown(shutdownCtx)
+		if err != nil {
+			log.Printf("Failed to stop webhook server: %v", err)
+		}
+	}()
+
+	return updates, nil
+}
+
+// poll deletes any webhook, since Telegram refuses getUpdates while one is
+// set, and long polls until ctx is done. The returned channel is closed right
+// away then, not after the poll in flight.
+func (t *Telegram) poll(ctx context.Context) (tgbotapi.UpdatesChannel, error) {
+	_, err := t.bot.Request(tgbotapi.DeleteWebhookConfig{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = 60
+
+	received := t.bot.GetUpdatesChan(u)
+	updates := make(chan tgbotapi.Update)
+	go func() {
+		defer close(updates)
+
+		for {
+			select {
+			case update, ok := <-received:
+				if !ok {
+					return
+				}
+				updates <- update
+			case <-ctx.Done():
+				t.bot.StopReceivingUpdates()
+				forwardReceived(received, updates)
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// forwardReceived passes on the updates already received without waiting for
+// the long poll in flight. That poll confirmed them to Telegram, while its own
+// updates are not confirmed until the next one and get delivered again.
+func forwardReceived(received <-chan tgbotapi.Update, updates chan<- tgbotapi.Update) {
+	for {
+		select {
+		case update, ok := <-received:
+			if !ok {
+				return
+			}
+			updates <- update
+		default:
+			return
+		}
+	}
+}
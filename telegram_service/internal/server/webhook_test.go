@@ -0,0 +1,60 @@
+package server
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	const update = `{"update_id":7,"message":{"message_id":1,"chat":{"id":42},"text":"Minsk"}}`
+
+	var useCase = []struct {
+		Name    string
+		Method  string
+		Secret  string
+		Body    string
+		Stopped bool
+		Code    int
+		IsError bool
+	}{
+		{Name: "Update", Method: http.MethodPost, Secret: "secret", Body: update, Code: http.StatusOK, IsError: false},
+		{Name: "Wrong secret", Method: http.MethodPost, Secret: "guess", Body: update, Code: http.StatusUnauthorized, IsError: true},
+		{Name: "No secret", Method: http.MethodPost, Body: update, Code: http.StatusUnauthorized, IsError: true},
+		{Name: "Bad body", Method: http.MethodPost, Secret: "secret", Body: `{not json`, Code: http.StatusBadRequest, IsError: true},
+		{Name: "Shutting down", Method: http.MethodPost, Secret: "secret", Body: update, Stopped: true,
+			Code: http.StatusServiceUnavailable, IsError: true},
+		{Name: "Get", Method: http.MethodGet, Secret: "secret", Code: http.StatusMethodNotAllowed, IsError: true},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			updates := make(chan tgbotapi.Update, 1)
+			done := make(chan struct{})
+			if us.Stopped {
+				close(done)
+			}
+			h := &webhookHandler{secret: "secret", updates: updates, done: done}
+
+			req := httptest.NewRequest(us.Method, "/webhook", strings.NewReader(us.Body))
+			if us.Secret != "" {
+				req.Header.Set(secretHeader, us.Secret)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, us.Code, rec.Code)
+			if us.IsError {
+				assert.Empty(t, updates)
+			} else {
+				update := <-updates
+				assert.Equal(t, 7, update.UpdateID)
+				assert.Equal(t, int64(42), update.Message.Chat.ID)
+				assert.Equal(t, "Minsk", update.Message.Text)
+			}
+		})
+	}
+}
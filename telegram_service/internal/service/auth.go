@@ -2,62 +2,197 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"log"
 	"strings"
 	pb2 "telegram_service/cmd/user/pb"
+	"telegram_service/internal/session"
+	"time"
+)
+
+// refreshBefore is how long before expiry a token gets refreshed.
+const refreshBefore = 5 * time.Minute
+
+var (
+	ErrNotLoggedIn = errors.New("not logged in")
+	// ErrMalformedCredentials is text that is not "<login> <password>".
+	ErrMalformedCredentials = errors.New("malformed credentials")
+	// ErrWrongCredentials is a login the user service rejected.
+	ErrWrongCredentials = errors.New("wrong login or password")
 )
 
 type AuthService struct {
-	m map[int64]bool
+	sessions session.Store
+	users    pb2.UserServiceClient
+	timeout  time.Duration
 }
 
-func NewAuthService() *AuthService {
+func NewAuthService(sessions session.Store, users pb2.UserServiceClient, timeout time.Duration) *AuthService {
 	return &AuthService{
-		m: map[int64]bool{},
+		sessions: sessions,
+		users:    users,
+		timeout:  timeout,
 	}
 }
 
 func (a *AuthService) CheckAuth(id int64) bool {
-	v, ok := a.m[id]
-	if ok {
-		return v
-	} else {
+	s, ok, err := a.sessions.Get(id)
+	if err != nil {
+		log.Printf("Failed to load session: %v", err)
 		return false
 	}
+	return ok && s.LoggedIn()
 }
 
-func (a *AuthService) Auth(text string, id int64) bool {
-	login, password, err := SplitString(text)
+func (a *AuthService) Logout(id int64) bool {
+	ok := a.CheckAuth(id)
+	err := a.sessions.Delete(id)
 	if err != nil {
-		a.m[id] = false
-		return false
+		log.Printf("Failed to delete session: %v", err)
 	}
-	conn, err := grpc.Dial("localhost:8085", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	return ok
+}
+
+// ActiveSessions returns how many chats are logged in.
+func (a *AuthService) ActiveSessions() (int, error) {
+	return a.sessions.Count()
+}
+
+// Session returns the session of a logged in chat.
+func (a *AuthService) Session(id int64) (session.Session, error) {
+	s, ok, err := a.sessions.Get(id)
 	if err != nil {
-		log.Printf("Failed to connect: %v", err)
-		a.m[id] = false
-		return false
+		return session.Session{}, err
+	}
+	if !ok || !s.LoggedIn() {
+		return session.Session{}, ErrNotLoggedIn
+	}
+	return s, nil
+}
+
+// Auth logs the chat in with "<login> <password>".
+func (a *AuthService) Auth(text string, id int64) error {
+	login, password, err := SplitString(text)
+	if err != nil {
+		a.Logout(id)
+		return ErrMalformedCredentials
 	}
-	defer conn.Close()
+	return a.Login(login, password, id)
+}
 
-	userClient := pb2.NewUserServiceClient(conn)
+// Login exchanges the credentials for a session of the chat. It fails with
+// ErrWrongCredentials when the user service rejects them, and with another
+// error when it could not check them.
+func (a *AuthService) Login(login, password string, id int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
 
 	req := &pb2.Request{
 		Login:    login,
 		Password: password,
 	}
 
-	res, err := userClient.Get(context.Background(), req)
+	res, err := a.users.Login(ctx, req)
+	if status.Code(err) == codes.Unauthenticated {
+		a.Logout(id)
+		return ErrWrongCredentials
+	}
 	if err != nil {
-		log.Printf("Failed to call authorization: %v", err)
-		return false
+		a.Logout(id)
+		return fmt.Errorf("failed to call authorization: %w", err)
 	}
-	a.m[id] = res.GetResponse()
 
-	return res.GetResponse()
+	err = a.sessions.Set(id, newSession(res))
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// Register creates an account linked to the chat and starts its session.
+// Errors are the user service's gRPC statuses.
+func (a *AuthService) Register(name, login, password string, id int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+
+	req := &pb2.CreateUserRequest{
+		Name:       name,
+		Login:      login,
+		Password:   password,
+		TelegramId: id,
+	}
+
+	res, err := a.users.CreateUser(ctx, req)
+	if err != nil {
+		log.Printf("Failed to call CreateUser: %v", err)
+		return err
+	}
+
+	err = a.sessions.Set(id, newSession(res))
+	if err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+	return nil
+}
+
+// Context returns ctx carrying the chat's token for downstream calls,
+// refreshing the token when it is about to expire.
+func (a *AuthService) Context(ctx context.Context, id int64) (context.Context, error) {
+	s, err := a.Session(id)
+	if err != nil {
+		return ctx, err
+	}
+
+	if time.Until(s.ExpiresAt) < refreshBefore {
+		s, err = a.refresh(ctx, id, s)
+		if err != nil {
+			return ctx, err
+		}
+	}
+	return WithToken(ctx, s.Token), nil
+}
+
+func (a *AuthService) refresh(ctx context.Context, id int64, s session.Session) (session.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	res, err := a.users.Refresh(ctx, &pb2.RefreshRequest{Token: s.Token})
+	if err != nil {
+		log.Printf("Failed to refresh token: %v", err)
+		if time.Now().Before(s.ExpiresAt) {
+			return s, nil
+		}
+		a.Logout(id)
+		return s, ErrNotLoggedIn
+	}
+
+	dialog := s.Dialog
+	s = newSession(res)
+	s.Dialog = dialog
+	err = a.sessions.Set(id, s)
+	if err != nil {
+		return s, fmt.Errorf("failed to save session: %w", err)
+	}
+	return s, nil
+}
+
+// WithToken attaches the token to outgoing gRPC calls.
+func WithToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func newSession(res *pb2.LoginResponse) session.Session {
+	return session.Session{
+		UserID:    res.GetUser().GetId(),
+		Name:      res.GetUser().GetName(),
+		Login:     res.GetUser().GetLogin(),
+		Token:     res.GetToken(),
+		ExpiresAt: time.Unix(res.GetExpiresAt(), 0),
+	}
 }
 
 func SplitString(s string) (login string, password string, err error) {
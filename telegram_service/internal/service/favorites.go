@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"log"
+	pb2 "telegram_service/cmd/user/pb"
+	"time"
+)
+
+// FavoritesService keeps the favorite cities in the user's profile. Calls need
+// a context from AuthService.Context.
+type FavoritesService struct {
+	users   pb2.UserServiceClient
+	timeout time.Duration
+}
+
+func NewFavoritesService(users pb2.UserServiceClient, timeout time.Duration) *FavoritesService {
+	return &FavoritesService{
+		users:   users,
+		timeout: timeout,
+	}
+}
+
+func (f *FavoritesService) List(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	res, err := f.users.GetFavorites(ctx, &pb2.FavoritesRequest{})
+	if err != nil {
+		log.Printf("Failed to call GetFavorites: %v", err)
+		return nil, err
+	}
+	return res.GetCities(), nil
+}
+
+// Add returns the favorites with the city added.
+func (f *FavoritesService) Add(ctx context.Context, city string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	res, err := f.users.AddFavorite(ctx, &pb2.FavoriteRequest{City: city})
+	if err != nil {
+		log.Printf("Failed to call AddFavorite: %v", err)
+		return nil, err
+	}
+	return res.GetCities(), nil
+}
+
+// Remove returns the favorites left.
+func (f *FavoritesService) Remove(ctx context.Context, city string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	res, err := f.users.RemoveFavorite(ctx, &pb2.FavoriteRequest{City: city})
+	if err != nil {
+		log.Printf("Failed to call RemoveFavorite: %v", err)
+		return nil, err
+	}
+	return res.GetCities(), nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"sync"
+	"time"
+)
+
+// Health is how an upstream answered a health check.
+type Health struct {
+	Name    string
+	Status  string
+	Latency time.Duration
+	Err     error
+}
+
+// HealthService asks the upstreams how they are with the standard gRPC health
+// protocol.
+type HealthService struct {
+	names   []string
+	clients []grpc_health_v1.HealthClient
+	timeout time.Duration
+}
+
+func NewHealthService(weather, users grpc.ClientConnInterface, timeout time.Duration) *HealthService {
+	return &HealthService{
+		names:   []string{"weather", "user"},
+		clients: []grpc_health_v1.HealthClient{grpc_health_v1.NewHealthClient(weather), grpc_health_v1.NewHealthClient(users)},
+		timeout: timeout,
+	}
+}
+
+// Check asks all the upstreams at once.
+func (h *HealthService) Check(ctx context.Context) []Health {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	health := make([]Health, len(h.clients))
+	var wg sync.WaitGroup
+	for i, c := range h.clients {
+		wg.Add(1)
+		go func(i int, c grpc_health_v1.HealthClient) {
+			defer wg.Done()
+
+			start := time.Now()
+			res, err := c.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+			health[i] = Health{Name: h.names[i], Status: res.GetStatus().String(), Latency: time.Since(start), Err: err}
+		}(i, c)
+	}
+	wg.Wait()
+	return health
+}
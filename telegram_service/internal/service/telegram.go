@@ -2,34 +2,214 @@ package service
 
 import (
 	"context"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"fmt"
 	"log"
+	"strings"
+	"sync"
 	pb2 "telegram_service/cmd/weather/pb"
+	"time"
 )
 
-type TgService struct{}
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// Query says what weather to look up. Coord, when set, takes precedence over
+// City, which then only names the place. Lang is the language of the weather
+// description, English when empty.
+type Query struct {
+	City  string
+	Units string
+	Lang  string
+	Coord *Coord
+}
+
+type Coord struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Place is a geocoding match for a city name.
+type Place struct {
+	Name    string
+	State   string
+	Country string
+	Coord   Coord
+}
+
+// Label names the place precisely enough to tell it from its namesakes.
+func (p Place) Label() string {
+	parts := []string{p.Name}
+	if p.State != "" {
+		parts = append(parts, p.State)
+	}
+	if p.Country != "" {
+		parts = append(parts, p.Country)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Conditions are the current values alerts watch: temperature in °C, wind in
+// m/s and rain in mm/h over the next hour.
+type Conditions struct {
+	Temp      float64
+	WindSpeed float64
+	Rain      float64
+}
+
+type TgService struct {
+	weather pb2.GetWeatherClient
+	timeout time.Duration
+}
 
-func (t *TgService) GetWeather(city string) (string, error) {
+func NewTgService(weather pb2.GetWeatherClient, timeout time.Duration) *TgService {
+	return &TgService{
+		weather: weather,
+		timeout: timeout,
+	}
+}
+
+func (t *TgService) GetWeather(ctx context.Context, q Query) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	req := &pb2.Request{
+		City:  q.City,
+		Units: q.Units,
+		Coord: q.Coord.proto(),
+		Lang:  q.Lang,
+	}
 
-	conn, err := grpc.Dial("localhost:8083", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	res, err := t.weather.Get(ctx, req)
 	if err != nil {
-		log.Printf("Failed to connect: %v", err)
+		log.Printf("Failed to call GetWeather: %v", err)
 		return "", err
 	}
-	defer conn.Close()
 
-	weatherClient := pb2.NewGetWeatherClient(conn)
+	return res.GetResponse(), nil
+}
 
-	req := &pb2.Request{
-		City: city,
+func (t *TgService) GetForecast(ctx context.Context, q Query, days int) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	req := &pb2.ForecastRequest{
+		City:  q.City,
+		Days:  int32(days),
+		Units: q.Units,
+		Coord: q.Coord.proto(),
+		Lang:  q.Lang,
 	}
 
-	res, err := weatherClient.Get(context.Background(), req)
+	res, err := t.weather.Forecast(ctx, req)
+	if err != nil {
+		log.Printf("Failed to call Forecast: %v", err)
+		return "", err
+	}
+
+	return res.GetResponse(), nil
+}
+
+// GetWeatherAll looks up the queries in parallel. A failed lookup leaves an
+// empty string.
+func (t *TgService) GetWeatherAll(ctx context.Context, qs []Query) []string {
+	res := make([]string, len(qs))
+
+	var wg sync.WaitGroup
+	for i, q := range qs {
+		wg.Add(1)
+		go func(i int, q Query) {
+			defer wg.Done()
+			res[i], _ = t.GetWeather(ctx, q)
+		}(i, q)
+	}
+	wg.Wait()
+
+	return res
+}
+
+// Conditions fetches the current conditions in the city. Rain is only looked
+// up when asked for.
+func (t *TgService) Conditions(ctx context.Context, city string, rain bool) (Conditions, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	res, err := t.weather.Get(ctx, &pb2.Request{City: city})
 	if err != nil {
 		log.Printf("Failed to call GetWeather: %v", err)
+		return Conditions{}, err
+	}
+	if res.GetCity() == "" {
+		return Conditions{}, fmt.Errorf("no weather for %s", city)
+	}
+
+	c := Conditions{Temp: res.GetTemp(), WindSpeed: res.GetWindSpeed()}
+	if !rain {
+		return c, nil
+	}
+
+	nowcast, err := t.weather.Nowcast(ctx, &pb2.NowcastRequest{City: city, Hours: 1})
+	if err != nil {
+		log.Printf("Failed to call Nowcast: %v", err)
+		return Conditions{}, err
+	}
+	for _, p := range append(nowcast.GetMinutely(), nowcast.GetHourly()...) {
+		if p.GetIntensity() > c.Rain {
+			c.Rain = p.GetIntensity()
+		}
+	}
+	return c, nil
+}
+
+// Timezone returns the IANA time zone name of the city.
+func (t *TgService) Timezone(ctx context.Context, city string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	res, err := t.weather.Forecast(ctx, &pb2.ForecastRequest{City: city, Days: 1})
+	if err != nil {
+		log.Printf("Failed to call Forecast: %v", err)
 		return "", err
 	}
+	if res.GetTimezone() == "" {
+		return "", fmt.Errorf("no time zone for %s", city)
+	}
+	return res.GetTimezone(), nil
+}
+
+// Geocode lists the places called city, without duplicates.
+func (t *TgService) Geocode(ctx context.Context, city string) ([]Place, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	res, err := t.weather.Geocode(ctx, &pb2.GeocodeRequest{City: city})
+	if err != nil {
+		log.Printf("Failed to call Geocode: %v", err)
+		return nil, err
+	}
 
-	return res.String(), nil
+	seen := map[string]bool{}
+	places := make([]Place, 0, len(res.GetPlaces()))
+	for _, p := range res.GetPlaces() {
+		place := Place{
+			Name:    p.GetName(),
+			State:   p.GetState(),
+			Country: p.GetCountry(),
+			Coord:   Coord{Lat: p.GetCoord().GetLat(), Lon: p.GetCoord().GetLon()},
+		}
+		if seen[place.Label()] {
+			continue
+		}
+		seen[place.Label()] = true
+		places = append(places, place)
+	}
+	return places, nil
+}
+
+func (c *Coord) proto() *pb2.Coordinates {
+	if c == nil {
+		return nil
+	}
+	return &pb2.Coordinates{Lat: c.Lat, Lon: c.Lon}
 }
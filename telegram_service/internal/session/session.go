@@ -0,0 +1,171 @@
+package session
+
+import (
+	"sync"
+	"telegram_service/internal/storage"
+	"time"
+)
+
+// Session is what the bot knows about a chat. Chats that are not logged in
+// have no token and only keep the state of a dialog.
+type Session struct {
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Login     string    `json:"login"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Dialog    *Dialog   `json:"dialog,omitempty"`
+}
+
+// Dialog is where a chat is in a multi-step conversation.
+type Dialog struct {
+	Name    string            `json:"name"`
+	Step    string            `json:"step"`
+	Data    map[string]string `json:"data,omitempty"`
+	Expires time.Time         `json:"expires"`
+}
+
+func (s Session) LoggedIn() bool {
+	return s.Token != ""
+}
+
+func (s Session) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+type Store interface {
+	Get(chatID int64) (Session, bool, error)
+	Set(chatID int64, s Session) error
+	Delete(chatID int64) error
+	// Count returns how many chats are logged in.
+	Count() (int, error)
+}
+
+// MemoryStore keeps sessions in memory until they expire. Sessions stored
+// without an expiry live for ttl.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[int64]Session
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		sessions: map[int64]Session{},
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+func (m *MemoryStore) Get(chatID int64) (Session, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[chatID]
+	if !ok {
+		return Session{}, false, nil
+	}
+	if s.Expired(m.now()) {
+		delete(m.sessions, chatID)
+		return Session{}, false, nil
+	}
+	return s, true, nil
+}
+
+func (m *MemoryStore) Set(chatID int64, s Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(chatID, s)
+	return nil
+}
+
+func (m *MemoryStore) Delete(chatID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, chatID)
+	return nil
+}
+
+func (m *MemoryStore) Count() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	n := 0
+	for _, s := range m.sessions {
+		if s.LoggedIn() && !s.Expired(now) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryStore) set(chatID int64, s Session) {
+	if s.ExpiresAt.IsZero() {
+		s.ExpiresAt = m.now().Add(m.ttl)
+	}
+	m.sessions[chatID] = s
+}
+
+// purge drops expired sessions. The caller holds the lock.
+func (m *MemoryStore) purge() {
+	now := m.now()
+	for id, s := range m.sessions {
+		if s.Expired(now) {
+			delete(m.sessions, id)
+		}
+	}
+}
+
+// FileStore is a MemoryStore that writes every change to a JSON file, so
+// sessions survive restarts.
+type FileStore struct {
+	MemoryStore
+	path string
+}
+
+func NewFileStore(path string, ttl time.Duration) (*FileStore, error) {
+	f := &FileStore{
+		MemoryStore: MemoryStore{
+			sessions: map[int64]Session{},
+			ttl:      ttl,
+			now:      time.Now,
+		},
+		path: path,
+	}
+
+	err := storage.ReadJSON(path, &f.sessions)
+	if err != nil {
+		return nil, err
+	}
+	if f.sessions == nil {
+		f.sessions = map[int64]Session{}
+	}
+	f.purge()
+	return f, nil
+}
+
+func (f *FileStore) Set(chatID int64, s Session) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.set(chatID, s)
+	return f.save()
+}
+
+func (f *FileStore) Delete(chatID int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.sessions, chatID)
+	return f.save()
+}
+
+// save writes the live sessions. The caller holds the lock.
+func (f *FileStore) save() error {
+	f.purge()
+	return storage.WriteJSON(f.path, f.sessions)
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const ttl = time.Hour
+
+var now = time.Date(2023, time.May, 10, 14, 0, 0, 0, time.UTC)
+
+func stores(t *testing.T) map[string]func() (Store, *MemoryStore) {
+	return map[string]func() (Store, *MemoryStore){
+		"memory": func() (Store, *MemoryStore) {
+			m := NewMemoryStore(ttl)
+			return m, m
+		},
+		"file": func() (Store, *MemoryStore) {
+			f, err := NewFileStore(filepath.Join(t.TempDir(), "sessions.json"), ttl)
+			require.NoError(t, err)
+			return f, &f.MemoryStore
+		},
+	}
+}
+
+func TestStore(t *testing.T) {
+	for name, newStore := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			store, m := newStore()
+			m.now = func() time.Time { return now }
+
+			_, ok, err := store.Get(1)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			require.NoError(t, store.Set(1, Session{UserID: "user", Token: "token"}))
+			require.NoError(t, store.Set(2, Session{UserID: "other", ExpiresAt: now.Add(time.Minute)}))
+
+			s, ok, err := store.Get(1)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, Session{UserID: "user", Token: "token", ExpiresAt: now.Add(ttl)}, s)
+
+			n, err := store.Count()
+			require.NoError(t, err)
+			assert.Equal(t, 1, n, "only chats with a token are logged in")
+
+			m.now = func() time.Time { return now.Add(time.Minute) }
+			_, ok, _ = store.Get(2)
+			assert.False(t, ok, "session expires with its token")
+			_, ok, _ = store.Get(1)
+			assert.True(t, ok)
+
+			m.now = func() time.Time { return now.Add(ttl) }
+			_, ok, _ = store.Get(1)
+			assert.False(t, ok, "session without expiry lives for ttl")
+			n, _ = store.Count()
+			assert.Zero(t, n)
+
+			require.NoError(t, store.Set(3, Session{UserID: "third"}))
+			require.NoError(t, store.Delete(3))
+			_, ok, _ = store.Get(3)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestStore_Concurrent(t *testing.T) {
+	for name, newStore := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			store, _ := newStore()
+
+			var wg sync.WaitGroup
+			for i := int64(0); i < 20; i++ {
+				wg.Add(1)
+				go func(id int64) {
+					defer wg.Done()
+					assert.NoError(t, store.Set(id, Session{UserID: "user"}))
+					_, ok, err := store.Get(id)
+					assert.NoError(t, err)
+					assert.True(t, ok)
+					assert.NoError(t, store.Delete(id))
+				}(i)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func TestFileStore_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	first, err := NewFileStore(path, ttl)
+	require.NoError(t, err)
+	require.NoError(t, first.Set(1, Session{UserID: "user", Token: "token", ExpiresAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, first.Set(2, Session{UserID: "gone"}))
+	require.NoError(t, first.Delete(2))
+
+	second, err := NewFileStore(path, ttl)
+	require.NoError(t, err)
+
+	s, ok, err := second.Get(1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "token", s.Token)
+
+	_, ok, _ = second.Get(2)
+	assert.False(t, ok)
+}
+
+func TestFileStore_DropsExpiredOnLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"1":{"user_id":"old","expires_at":"2020-01-01T00:00:00Z"}}`), 0o600))
+
+	f, err := NewFileStore(path, ttl)
+	require.NoError(t, err)
+	assert.Empty(t, f.sessions)
+}
+
+func TestFileStore_CorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{not json`), 0o600))
+
+	_, err := NewFileStore(path, ttl)
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+// Package stats counts what the bot handles, for operators.
+package stats
+
+import (
+	"sync"
+	"time"
+)
+
+type minute struct {
+	start    int64
+	messages int
+	errors   int
+}
+
+// Counter counts messages and the ones that failed over the last hour, in
+// one-minute buckets.
+type Counter struct {
+	mu      sync.Mutex
+	minutes [60]minute
+	started time.Time
+	now     func() time.Time
+}
+
+func NewCounter() *Counter {
+	return &Counter{started: time.Now(), now: time.Now}
+}
+
+// Record counts a handled message, failed when handling it failed.
+func (c *Counter) Record(failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	start := c.now().Unix() / 60
+	m := &c.minutes[start%int64(len(c.minutes))]
+	if m.start != start {
+		*m = minute{start: start}
+	}
+	m.messages++
+	if failed {
+		m.errors++
+	}
+}
+
+// LastHour returns the messages and errors counted in the last hour.
+func (c *Counter) LastHour() (messages, errors int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now().Unix() / 60
+	for _, m := range c.minutes {
+		if now-m.start < int64(len(c.minutes)) {
+			messages += m.messages
+			errors += m.errors
+		}
+	}
+	return messages, errors
+}
+
+// Uptime is the time since the counter was created.
+func (c *Counter) Uptime() time.Duration {
+	return c.now().Sub(c.started)
+}
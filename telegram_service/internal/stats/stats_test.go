@@ -0,0 +1,35 @@
+package stats
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestCounter(t *testing.T) {
+	now := time.Date(2023, time.May, 10, 14, 0, 0, 0, time.UTC)
+	c := NewCounter()
+	c.now = func() time.Time { return now }
+	c.started = now
+
+	c.Record(false)
+	c.Record(true)
+	now = now.Add(30 * time.Minute)
+	c.Record(false)
+
+	messages, errors := c.LastHour()
+	assert.Equal(t, 3, messages)
+	assert.Equal(t, 1, errors)
+	assert.Equal(t, 30*time.Minute, c.Uptime())
+
+	now = now.Add(31 * time.Minute)
+	messages, errors = c.LastHour()
+	assert.Equal(t, 1, messages, "older minutes fall out")
+	assert.Zero(t, errors)
+
+	now = now.Add(29 * time.Minute)
+	c.Record(true)
+	messages, errors = c.LastHour()
+	assert.Equal(t, 1, messages, "a reused bucket starts over")
+	assert.Equal(t, 1, errors)
+}
@@ -0,0 +1,328 @@
+// Package telegramtest is a fake Telegram Bot API for running the bot
+// offline. Tests play the users: they send messages and press buttons, and
+// read what the bot sends back.
+package telegramtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+// Bot is the account the fake server authorizes any token as.
+var Bot = tgbotapi.User{ID: 1, IsBot: true, FirstName: "Weather", UserName: "weather_test_bot"}
+
+// Call is a request of the bot that changes a chat.
+type Call struct {
+	Method    string
+	ChatID    int64
+	MessageID int
+	// CallbackID is the button press answerCallbackQuery answers.
+	CallbackID string
+	Text       string
+	// ReplyMarkup is the keyboard as JSON, empty without one.
+	ReplyMarkup string
+}
+
+// Buttons returns the callback data of the inline keyboard by button text.
+func (c Call) Buttons() map[string]string {
+	var markup tgbotapi.InlineKeyboardMarkup
+	_ = json.Unmarshal([]byte(c.ReplyMarkup), &markup)
+
+	buttons := map[string]string{}
+	for _, row := range markup.InlineKeyboard {
+		for _, b := range row {
+			if b.CallbackData != nil {
+				buttons[b.Text] = *b.CallbackData
+			}
+		}
+	}
+	return buttons
+}
+
+// Server serves the Bot API methods the bot uses: getMe, getUpdates,
+// sendMessage, editMessageText, answerCallbackQuery, deleteMessage and
+// getChatMember. Other methods succeed without doing anything.
+type Server struct {
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	updates []tgbotapi.Update
+	lastID  int
+	msgID   int
+	// presses maps the button presses to their chats.
+	presses map[string]int64
+	// admins are the group admins by group.
+	admins  map[int64]map[int64]bool
+	arrived chan struct{}
+	// closing ends the long polls, which otherwise last as long as the bot
+	// asks, like Telegram's.
+	closing chan struct{}
+	calls   chan Call
+}
+
+func NewServer() *Server {
+	s := &Server{
+		presses: map[string]int64{},
+		admins:  map[int64]map[int64]bool{},
+		arrived: make(chan struct{}),
+		closing: make(chan struct{}),
+		calls:   make(chan Call, 1000),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Endpoint is the API endpoint for tgbotapi.NewBotAPIWithAPIEndpoint.
+func (s *Server) Endpoint() string {
+	return s.srv.URL + "/bot%s/%s"
+}
+
+func (s *Server) Close() {
+	close(s.closing)
+	s.srv.Close()
+}
+
+// SendMessage delivers text from a user in their private chat and returns
+// the message ID. Text starting with "/" is marked as a command.
+func (s *Server) SendMessage(chatID int64, text string) int {
+	return s.SendFrom(chatID, chatID, text)
+}
+
+// SendFrom delivers text from a user in a chat, a supergroup when chatID is
+// negative as in Telegram.
+func (s *Server) SendFrom(chatID, userID int64, text string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.msgID++
+	msg := &tgbotapi.Message{
+		MessageID: s.msgID,
+		From:      user(userID),
+		Chat:      chat(chatID),
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	msg.Entities = Entities(text)
+
+	s.push(tgbotapi.Update{Message: msg})
+	return msg.MessageID
+}
+
+// Entities marks the command and the mentions in text the way Telegram does,
+// with offsets and lengths in UTF-16 code units.
+func Entities(text string) []tgbotapi.MessageEntity {
+	var entities []tgbotapi.MessageEntity
+	offset := 0
+	for i, word := range strings.Split(text, " ") {
+		length := len(utf16.Encode([]rune(word)))
+		switch {
+		case i == 0 && strings.HasPrefix(word, "/"):
+			entities = append(entities, tgbotapi.MessageEntity{Type: "bot_command", Offset: 0, Length: length})
+		case len(word) > 1 && strings.HasPrefix(word, "@"):
+			entities = append(entities, tgbotapi.MessageEntity{Type: "mention", Offset: offset, Length: length})
+		}
+		offset += length + 1
+	}
+	return entities
+}
+
+// Press presses a button with data under the bot's message.
+func (s *Server) Press(chatID int64, messageID int, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := strconv.Itoa(s.lastID + 1)
+	s.presses[id] = chatID
+	s.push(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      id,
+		From:    user(chatID),
+		Message: &tgbotapi.Message{MessageID: messageID, Chat: chat(chatID)},
+		Data:    data,
+	}})
+}
+
+// Promote makes the user an admin of the group.
+func (s *Server) Promote(chatID, userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.admins[chatID] == nil {
+		s.admins[chatID] = map[int64]bool{}
+	}
+	s.admins[chatID][userID] = true
+}
+
+// Pending counts the updates the bot has not confirmed yet.
+func (s *Server) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.updates)
+}
+
+// Next waits for the next call of the bot.
+func (s *Server) Next(timeout time.Duration) (Call, error) {
+	select {
+	case c := <-s.calls:
+		return c, nil
+	case <-time.After(timeout):
+		return Call{}, errors.New("the bot sent nothing")
+	}
+}
+
+// push queues an update and wakes the long polls. The caller holds the lock.
+func (s *Server) push(u tgbotapi.Update) {
+	s.lastID++
+	u.UpdateID = s.lastID
+	s.updates = append(s.updates, u)
+
+	close(s.arrived)
+	s.arrived = make(chan struct{})
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	// The path is /bot<token>/<method>.
+	method := r.URL.Path[strings.LastIndexByte(r.URL.Path, '/')+1:]
+	err := r.ParseForm()
+	if err != nil {
+		respond(w, nil, err)
+		return
+	}
+
+	var result interface{} = true
+	switch method {
+	case "getMe":
+		result = Bot
+	case "getUpdates":
+		result = s.getUpdates(r)
+	case "getChatMember":
+		result = s.chatMember(r)
+	case "sendMessage", "editMessageText":
+		result, err = s.record(r, method)
+	case "answerCallbackQuery", "deleteMessage":
+		_, err = s.record(r, method)
+	}
+	respond(w, result, err)
+}
+
+// getUpdates returns the updates from offset on, waiting for some up to the
+// poll timeout.
+func (s *Server) getUpdates(r *http.Request) []tgbotapi.Update {
+	offset, _ := strconv.Atoi(r.Form.Get("offset"))
+	timeout, _ := strconv.Atoi(r.Form.Get("timeout"))
+	wait := time.Duration(timeout) * time.Second
+	deadline := time.After(wait)
+
+	for {
+		s.mu.Lock()
+		for len(s.updates) > 0 && s.updates[0].UpdateID < offset {
+			s.updates = s.updates[1:]
+		}
+		updates := append([]tgbotapi.Update{}, s.updates...)
+		arrived := s.arrived
+		s.mu.Unlock()
+
+		if len(updates) > 0 {
+			return updates
+		}
+		select {
+		case <-arrived:
+		case <-deadline:
+			return updates
+		case <-r.Context().Done():
+			return updates
+		case <-s.closing:
+			return updates
+		}
+	}
+}
+
+func (s *Server) chatMember(r *http.Request) tgbotapi.ChatMember {
+	chatID, _ := strconv.ParseInt(r.Form.Get("chat_id"), 10, 64)
+	userID, _ := strconv.ParseInt(r.Form.Get("user_id"), 10, 64)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	member := tgbotapi.ChatMember{User: user(userID), Status: "member"}
+	if s.admins[chatID][userID] {
+		member.Status = "administrator"
+	}
+	return member
+}
+
+// record keeps the call for Next and returns the message it sent or edited.
+func (s *Server) record(r *http.Request, method string) (*tgbotapi.Message, error) {
+	chatID, _ := strconv.ParseInt(r.Form.Get("chat_id"), 10, 64)
+	messageID, _ := strconv.Atoi(r.Form.Get("message_id"))
+	c := Call{
+		Method:      method,
+		ChatID:      chatID,
+		MessageID:   messageID,
+		CallbackID:  r.Form.Get("callback_query_id"),
+		Text:        r.Form.Get("text"),
+		ReplyMarkup: r.Form.Get("reply_markup"),
+	}
+	if method == "answerCallbackQuery" {
+		s.mu.Lock()
+		c.ChatID = s.presses[c.CallbackID]
+		s.mu.Unlock()
+	}
+	if (method == "sendMessage" || method == "editMessageText") && c.Text == "" {
+		return nil, errors.New("Bad Request: message text is empty")
+	}
+
+	if method == "sendMessage" {
+		s.mu.Lock()
+		s.msgID++
+		c.MessageID = s.msgID
+		s.mu.Unlock()
+	}
+
+	select {
+	case s.calls <- c:
+	default:
+		return nil, fmt.Errorf("too many calls not read by the test")
+	}
+
+	return &tgbotapi.Message{
+		MessageID: c.MessageID,
+		From:      &Bot,
+		Chat:      chat(chatID),
+		Date:      int(time.Now().Unix()),
+		Text:      c.Text,
+	}, nil
+}
+
+func respond(w http.ResponseWriter, result interface{}, err error) {
+	res := map[string]interface{}{"ok": err == nil}
+	if err != nil {
+		res["error_code"] = http.StatusBadRequest
+		res["description"] = err.Error()
+	} else {
+		res["result"] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func user(id int64) *tgbotapi.User {
+	return &tgbotapi.User{ID: id, FirstName: "User", UserName: fmt.Sprintf("user%d", id)}
+}
+
+func chat(id int64) *tgbotapi.Chat {
+	if id < 0 {
+		return &tgbotapi.Chat{ID: id, Type: "supergroup", Title: "Group"}
+	}
+	return &tgbotapi.Chat{ID: id, Type: "private"}
+}
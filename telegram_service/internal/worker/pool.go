@@ -0,0 +1,114 @@
+package worker
+
+import (
+	"errors"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+var (
+	ErrQueueFull = errors.New("queue is full")
+	ErrClosed    = errors.New("pool is closed")
+)
+
+// Pool runs jobs on a fixed number of workers. Every key has its own queue,
+// so jobs with the same key run one after another in submission order, while
+// a slow key only holds up its own jobs. Workers take turns between the keys.
+type Pool struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	depth int
+	// queues holds the waiting jobs of every key that has some or is running.
+	queues map[int64][]func()
+	// ready lists the keys with waiting jobs and no running one, oldest first.
+	ready  []int64
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewPool starts workers sharing queues of up to depth waiting jobs per key.
+func NewPool(workers, depth int) *Pool {
+	p := &Pool{depth: depth, queues: map[int64][]func(){}}
+	p.cond = sync.NewCond(&p.mu)
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues job behind the earlier jobs of key. It never blocks: when the
+// key already has depth jobs waiting the job is refused with ErrQueueFull.
+func (p *Pool) Submit(key int64, job func()) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	q, busy := p.queues[key]
+	if len(q) >= p.depth {
+		return ErrQueueFull
+	}
+	p.queues[key] = append(q, job)
+	if !busy {
+		p.ready = append(p.ready, key)
+		p.cond.Signal()
+	}
+	return nil
+}
+
+// Close waits for the queued jobs to finish. Later Submits fail with ErrClosed.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	p.wg.Wait()
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for {
+		p.mu.Lock()
+		for len(p.ready) == 0 && !p.closed {
+			p.cond.Wait()
+		}
+		if len(p.ready) == 0 {
+			p.mu.Unlock()
+			return
+		}
+
+		key := p.ready[0]
+		p.ready = p.ready[1:]
+		job := p.queues[key][0]
+		p.queues[key] = p.queues[key][1:]
+		p.mu.Unlock()
+
+		run(job)
+
+		p.mu.Lock()
+		if len(p.queues[key]) > 0 {
+			p.ready = append(p.ready, key)
+			p.cond.Signal()
+		} else {
+			delete(p.queues, key)
+		}
+		p.mu.Unlock()
+	}
+}
+
+// run keeps a panicking job from taking the whole bot down.
+func run(job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job panicked: %v\n%s", r, debug.Stack())
+		}
+	}()
+
+	job()
+}
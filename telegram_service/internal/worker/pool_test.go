@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPool_KeepsOrderPerKey(t *testing.T) {
+	p := NewPool(4, 50)
+
+	var mu sync.Mutex
+	got := map[int64][]int{}
+	for i := 0; i < 50; i++ {
+		for key := int64(-3); key <= 3; key++ {
+			key, i := key, i
+			assert.NoError(t, p.Submit(key, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				got[key] = append(got[key], i)
+			}))
+		}
+	}
+	p.Close()
+
+	for key := int64(-3); key <= 3; key++ {
+		assert.Len(t, got[key], 50)
+		for i, v := range got[key] {
+			assert.Equal(t, i, v)
+		}
+	}
+}
+
+func TestPool_RunsKeysInParallel(t *testing.T) {
+	p := NewPool(2, 1)
+	defer p.Close()
+
+	release := make(chan struct{})
+	assert.NoError(t, p.Submit(0, func() { <-release }))
+
+	// 2 would have shared a queue with 0 if keys were spread over workers.
+	done := make(chan struct{})
+	assert.NoError(t, p.Submit(2, func() { close(done) }))
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a slow chat blocks the others")
+	}
+	close(release)
+}
+
+func TestPool_BoundedQueue(t *testing.T) {
+	p := NewPool(1, 1)
+	defer p.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	assert.NoError(t, p.Submit(0, func() {
+		close(started)
+		<-release
+	}))
+	<-started
+	assert.NoError(t, p.Submit(0, func() {}))
+
+	assert.ErrorIs(t, p.Submit(0, func() {}), ErrQueueFull)
+	assert.NoError(t, p.Submit(1, func() {}), "other keys have queues of their own")
+}
+
+func TestPool_SurvivesPanic(t *testing.T) {
+	p := NewPool(1, 2)
+
+	ran := false
+	assert.NoError(t, p.Submit(0, func() { panic("boom") }))
+	assert.NoError(t, p.Submit(0, func() { ran = true }))
+	p.Close()
+
+	assert.True(t, ran, "the worker goes on after a panic")
+}
+
+func TestPool_CloseDrainsQueue(t *testing.T) {
+	p := NewPool(2, 10)
+
+	var mu sync.Mutex
+	count := 0
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, p.Submit(int64(i), func() {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			count++
+			mu.Unlock()
+		}))
+	}
+	p.Close()
+
+	assert.Equal(t, 10, count)
+	assert.ErrorIs(t, p.Submit(0, func() {}), ErrClosed)
+}
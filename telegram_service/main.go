@@ -1,11 +1,25 @@
 package main
 
 import (
+	"context"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
+	"syscall"
+	userpb "telegram_service/cmd/user/pb"
+	weatherpb "telegram_service/cmd/weather/pb"
+	"telegram_service/internal/alert"
+	"telegram_service/internal/chats"
+	"telegram_service/internal/client"
 	"telegram_service/internal/config"
+	"telegram_service/internal/dialog"
+	"telegram_service/internal/prefs"
+	"telegram_service/internal/ratelimit"
+	"telegram_service/internal/scheduler"
 	"telegram_service/internal/server"
 	"telegram_service/internal/service"
+	"telegram_service/internal/session"
 )
 
 func main() {
@@ -23,11 +37,71 @@ func main() {
 		logger.Fatal(err)
 	}
 
-	authService := service.NewAuthService()
+	sessions, err := newSessionStore(cfg.Session)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	// Both upstreams share the cap on calls in flight.
+	limit := client.Limit(ratelimit.NewSemaphore(cfg.Limits.Upstream))
+
+	weatherConn, err := client.Dial(cfg.Weather, limit)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer weatherConn.Close()
+
+	userConn, err := client.Dial(cfg.User, limit)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer userConn.Close()
+
+	users := userpb.NewUserServiceClient(userConn)
+
+	authService := service.NewAuthService(sessions, users, cfg.User.Timeout)
+
+	favoriteService := service.NewFavoritesService(users, cfg.User.Timeout)
 
-	tgService := service.TgService{}
+	tgService := service.NewTgService(weatherpb.NewGetWeatherClient(weatherConn), cfg.Weather.Timeout)
 
-	tgConnect := server.NewTelegram(&cfg, &tgService, authService)
+	healthService := service.NewHealthService(weatherConn, userConn, cfg.Weather.Timeout)
 
-	tgConnect.Start()
+	known, err := chats.NewStore(cfg.Chats.Path)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	subscriptions, err := scheduler.NewStore(cfg.Scheduler.Path)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	alerts, err := alert.NewStore(cfg.Alerts.Path)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	preferences, err := prefs.NewStore(cfg.Prefs.Path)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	tgConnect := server.NewTelegram(&cfg, tgService, authService, favoriteService, healthService, dialog.NewManager(sessions), known,
+		subscriptions, alerts, preferences)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = tgConnect.Start(ctx)
+	if err != nil {
+		logger.Fatal(err)
+	}
+}
+
+func newSessionStore(cfg config.Session) (session.Store, error) {
+	if cfg.Store == config.SessionFile {
+		return session.NewFileStore(cfg.Path, cfg.TTL)
+	}
+	return session.NewMemoryStore(cfg.TTL), nil
 }
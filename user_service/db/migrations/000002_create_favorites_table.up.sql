@@ -0,0 +1,6 @@
+CREATE TABLE favorites (
+                        user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+                        city text NOT NULL,
+                        created_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX favorites_user_city ON favorites (user_id, lower(city));
\ No newline at end of file
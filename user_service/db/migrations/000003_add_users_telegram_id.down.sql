@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS telegram_id;
\ No newline at end of file
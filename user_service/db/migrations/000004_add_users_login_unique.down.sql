@@ -0,0 +1 @@
+DROP INDEX IF EXISTS users_login;
\ No newline at end of file
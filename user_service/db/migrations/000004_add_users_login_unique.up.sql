@@ -0,0 +1 @@
+CREATE UNIQUE INDEX users_login ON users (login);
\ No newline at end of file
@@ -36,6 +36,21 @@ func (m *Mockcontroller) EXPECT() *MockcontrollerMockRecorder {
 	return m.recorder
 }
 
+// AddFavorite mocks base method.
+func (m *Mockcontroller) AddFavorite(ctx context.Context, req *pb.FavoriteRequest) (*pb.FavoritesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddFavorite", ctx, req)
+	ret0, _ := ret[0].(*pb.FavoritesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddFavorite indicates an expected call of AddFavorite.
+func (mr *MockcontrollerMockRecorder) AddFavorite(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFavorite", reflect.TypeOf((*Mockcontroller)(nil).AddFavorite), ctx, req)
+}
+
 // Authorize mocks base method.
 func (m *Mockcontroller) Authorize(ctx context.Context, login, password string) (string, error) {
 	m.ctrl.T.Helper()
@@ -65,6 +80,36 @@ func (mr *MockcontrollerMockRecorder) Create(context, user interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*Mockcontroller)(nil).Create), context, user)
 }
 
+// CreateUser mocks base method.
+func (m *Mockcontroller) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, req)
+	ret0, _ := ret[0].(*pb.LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockcontrollerMockRecorder) CreateUser(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*Mockcontroller)(nil).CreateUser), ctx, req)
+}
+
+// DeleteFavorite mocks base method.
+func (m *Mockcontroller) DeleteFavorite(ctx context.Context, userID, city string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFavorite", ctx, userID, city)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFavorite indicates an expected call of DeleteFavorite.
+func (mr *MockcontrollerMockRecorder) DeleteFavorite(ctx, userID, city interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFavorite", reflect.TypeOf((*Mockcontroller)(nil).DeleteFavorite), ctx, userID, city)
+}
+
 // DeleteUser mocks base method.
 func (m *Mockcontroller) DeleteUser(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -79,6 +124,21 @@ func (mr *MockcontrollerMockRecorder) DeleteUser(ctx, id interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*Mockcontroller)(nil).DeleteUser), ctx, id)
 }
 
+// Favorites mocks base method.
+func (m *Mockcontroller) Favorites(ctx context.Context, userID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Favorites", ctx, userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Favorites indicates an expected call of Favorites.
+func (mr *MockcontrollerMockRecorder) Favorites(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Favorites", reflect.TypeOf((*Mockcontroller)(nil).Favorites), ctx, userID)
+}
+
 // Get mocks base method.
 func (m *Mockcontroller) Get(ctx context.Context, req *pb.Request) (*pb.Response, error) {
 	m.ctrl.T.Helper()
@@ -109,6 +169,21 @@ func (mr *MockcontrollerMockRecorder) GetAllUsers(ctx interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUsers", reflect.TypeOf((*Mockcontroller)(nil).GetAllUsers), ctx)
 }
 
+// GetFavorites mocks base method.
+func (m *Mockcontroller) GetFavorites(ctx context.Context, req *pb.FavoritesRequest) (*pb.FavoritesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFavorites", ctx, req)
+	ret0, _ := ret[0].(*pb.FavoritesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFavorites indicates an expected call of GetFavorites.
+func (mr *MockcontrollerMockRecorder) GetFavorites(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFavorites", reflect.TypeOf((*Mockcontroller)(nil).GetFavorites), ctx, req)
+}
+
 // GetUser mocks base method.
 func (m *Mockcontroller) GetUser(ctx context.Context, id string) (model.User, error) {
 	m.ctrl.T.Helper()
@@ -124,6 +199,66 @@ func (mr *MockcontrollerMockRecorder) GetUser(ctx, id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*Mockcontroller)(nil).GetUser), ctx, id)
 }
 
+// Login mocks base method.
+func (m *Mockcontroller) Login(ctx context.Context, req *pb.Request) (*pb.LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Login", ctx, req)
+	ret0, _ := ret[0].(*pb.LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Login indicates an expected call of Login.
+func (mr *MockcontrollerMockRecorder) Login(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*Mockcontroller)(nil).Login), ctx, req)
+}
+
+// Refresh mocks base method.
+func (m *Mockcontroller) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.LoginResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", ctx, req)
+	ret0, _ := ret[0].(*pb.LoginResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockcontrollerMockRecorder) Refresh(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*Mockcontroller)(nil).Refresh), ctx, req)
+}
+
+// RemoveFavorite mocks base method.
+func (m *Mockcontroller) RemoveFavorite(ctx context.Context, req *pb.FavoriteRequest) (*pb.FavoritesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFavorite", ctx, req)
+	ret0, _ := ret[0].(*pb.FavoritesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveFavorite indicates an expected call of RemoveFavorite.
+func (mr *MockcontrollerMockRecorder) RemoveFavorite(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFavorite", reflect.TypeOf((*Mockcontroller)(nil).RemoveFavorite), ctx, req)
+}
+
+// SaveFavorite mocks base method.
+func (m *Mockcontroller) SaveFavorite(ctx context.Context, userID, city string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFavorite", ctx, userID, city)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveFavorite indicates an expected call of SaveFavorite.
+func (mr *MockcontrollerMockRecorder) SaveFavorite(ctx, userID, city interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFavorite", reflect.TypeOf((*Mockcontroller)(nil).SaveFavorite), ctx, userID, city)
+}
+
 // UpdateUser mocks base method.
 func (m *Mockcontroller) UpdateUser(ctx context.Context, user model.User) error {
 	m.ctrl.T.Helper()
@@ -35,6 +35,20 @@ func (m *Mockrepository) EXPECT() *MockrepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddFavorite mocks base method.
+func (m *Mockrepository) AddFavorite(ctx context.Context, userID, city string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddFavorite", ctx, userID, city)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddFavorite indicates an expected call of AddFavorite.
+func (mr *MockrepositoryMockRecorder) AddFavorite(ctx, userID, city interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFavorite", reflect.TypeOf((*Mockrepository)(nil).AddFavorite), ctx, userID, city)
+}
+
 // CheckAuth mocks base method.
 func (m *Mockrepository) CheckAuth(ctx context.Context, login, password string) (model.User, error) {
 	m.ctrl.T.Helper()
@@ -93,6 +107,21 @@ func (mr *MockrepositoryMockRecorder) GetAllUsers(ctx interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUsers", reflect.TypeOf((*Mockrepository)(nil).GetAllUsers), ctx)
 }
 
+// GetFavorites mocks base method.
+func (m *Mockrepository) GetFavorites(ctx context.Context, userID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFavorites", ctx, userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFavorites indicates an expected call of GetFavorites.
+func (mr *MockrepositoryMockRecorder) GetFavorites(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFavorites", reflect.TypeOf((*Mockrepository)(nil).GetFavorites), ctx, userID)
+}
+
 // GetUser mocks base method.
 func (m *Mockrepository) GetUser(ctx context.Context, id string) (model.User, error) {
 	m.ctrl.T.Helper()
@@ -108,6 +137,36 @@ func (mr *MockrepositoryMockRecorder) GetUser(ctx, id interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*Mockrepository)(nil).GetUser), ctx, id)
 }
 
+// LoginExists mocks base method.
+func (m *Mockrepository) LoginExists(ctx context.Context, login string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginExists", ctx, login)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginExists indicates an expected call of LoginExists.
+func (mr *MockrepositoryMockRecorder) LoginExists(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginExists", reflect.TypeOf((*Mockrepository)(nil).LoginExists), ctx, login)
+}
+
+// RemoveFavorite mocks base method.
+func (m *Mockrepository) RemoveFavorite(ctx context.Context, userID, city string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFavorite", ctx, userID, city)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveFavorite indicates an expected call of RemoveFavorite.
+func (mr *MockrepositoryMockRecorder) RemoveFavorite(ctx, userID, city interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFavorite", reflect.TypeOf((*Mockrepository)(nil).RemoveFavorite), ctx, userID, city)
+}
+
 // UpdateUser mocks base method.
 func (m *Mockrepository) UpdateUser(ctx context.Context, user model.User) error {
 	m.ctrl.T.Helper()
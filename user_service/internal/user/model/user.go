@@ -1,9 +1,16 @@
 package model
 
+import "errors"
+
+// ErrLoginTaken is returned when an account with the same login exists.
+var ErrLoginTaken = errors.New("login is already taken")
+
 type User struct {
 	ID          string
 	Name        string
 	Login       string
 	Password    string
 	Description string
+	// TelegramID is the chat the account was registered from, if any.
+	TelegramID int64
 }
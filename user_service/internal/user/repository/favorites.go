@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetFavorites returns the user's favorite cities in the order they were added.
+func (u *UserRepo) GetFavorites(ctx context.Context, userID string) ([]string, error) {
+	query := `SELECT city FROM favorites WHERE user_id = $1 ORDER BY created_at`
+
+	cities := make([]string, 0)
+
+	err := u.db.SelectContext(ctx, &cities, query, userID)
+	if err != nil {
+		return []string{}, fmt.Errorf("failed to select favorites: %w", err)
+	}
+
+	return cities, nil
+}
+
+// AddFavorite does nothing when the city is already a favorite, in any case.
+func (u *UserRepo) AddFavorite(ctx context.Context, userID, city string) error {
+	query := `INSERT INTO favorites(user_id, city) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	_, err := u.db.ExecContext(ctx, query, userID, city)
+	if err != nil {
+		return fmt.Errorf("failed to insert favorite: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveFavorite reports whether the city was a favorite.
+func (u *UserRepo) RemoveFavorite(ctx context.Context, userID, city string) (bool, error) {
+	query := `DELETE FROM favorites WHERE user_id = $1 AND lower(city) = lower($2)`
+
+	res, err := u.db.ExecContext(ctx, query, userID, city)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete favorite: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete favorite: %w", err)
+	}
+
+	return n > 0, nil
+}
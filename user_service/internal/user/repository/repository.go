@@ -3,15 +3,20 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"user_service/internal/config"
 	"user_service/internal/user/model"
 )
 
+// uniqueViolation is the postgres error code for a duplicate key.
+const uniqueViolation = "23505"
+
 type UserRepo struct {
 	db  *sqlx.DB
 	cfg *config.DB
@@ -46,11 +51,16 @@ func (u *UserRepo) RunMigrations() error {
 }
 
 func (u *UserRepo) CreateUser(ctx context.Context, modelUser model.User) error {
-	query := `INSERT INTO users(id, name, description, login, password) VALUES (:id, :name, :description, :login, :password)`
+	query := `INSERT INTO users(id, name, description, login, password, telegram_id)
+             VALUES (:id, :name, :description, :login, :password, :telegram_id)`
 
 	user := convertUser(modelUser)
 
 	_, err := u.db.NamedExecContext(ctx, query, &user)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation && pqErr.Constraint == "users_login" {
+		return model.ErrLoginTaken
+	}
 	if err != nil {
 		return fmt.Errorf("failed to insert user: %w", err)
 	}
@@ -141,6 +151,19 @@ func (u *UserRepo) CheckAuth(ctx context.Context, login, password string) (model
 
 }
 
+func (u *UserRepo) LoginExists(ctx context.Context, login string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE login = $1)`
+
+	var exists bool
+
+	err := u.db.GetContext(ctx, &exists, query, login)
+	if err != nil {
+		return false, fmt.Errorf("failed to check login: %w", err)
+	}
+
+	return exists, nil
+}
+
 func convertUser(modelUser model.User) user {
 	return user{
 		ID:       modelUser.ID,
@@ -151,6 +174,10 @@ func convertUser(modelUser model.User) user {
 			String: modelUser.Description,
 			Valid:  true,
 		},
+		TelegramID: sql.NullInt64{
+			Int64: modelUser.TelegramID,
+			Valid: modelUser.TelegramID != 0,
+		},
 	}
 }
 
@@ -161,5 +188,6 @@ func (u user) toModel() model.User {
 		Login:       u.Login,
 		Password:    u.Password,
 		Description: u.Description.String,
+		TelegramID:  u.TelegramID.Int64,
 	}
 }
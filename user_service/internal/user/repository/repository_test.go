@@ -59,6 +59,9 @@ func TestUserRepo_CreateUser(t *testing.T) {
 
 	errSuccessUser := userRepo.CreateUser(context.Background(), userSuccess)
 	errDublicateUser := userRepo.CreateUser(context.Background(), userSuccess)
+	userSameLogin := userSuccess
+	userSameLogin.ID = uuid.New().String()
+	errDublicateLogin := userRepo.CreateUser(context.Background(), userSameLogin)
 	errNullUser := userRepo.CreateUser(context.Background(), userNull)
 	errWithoutID := userRepo.CreateUser(context.Background(), userWithoutID)
 
@@ -66,6 +69,7 @@ func TestUserRepo_CreateUser(t *testing.T) {
 
 	assert.NoError(t, errSuccessUser)
 	assert.Error(t, errDublicateUser)
+	assert.ErrorIs(t, errDublicateLogin, model.ErrLoginTaken)
 	assert.Error(t, errNullUser)
 	assert.Error(t, errWithoutID)
 
@@ -195,6 +199,71 @@ func TestUserRepo_GetAllUsers(t *testing.T) {
 	assert.NotEmpty(t, users)
 }
 
+func TestUserRepo_LoginExists(t *testing.T) {
+	user := model.User{
+		ID:          uuid.New().String(),
+		Name:        "test",
+		Login:       uuid.New().String(),
+		Password:    "test",
+		Description: "",
+		TelegramID:  42,
+	}
+
+	createdUser(t, &user)
+	defer deleteUser(t, &user)
+
+	exists, err := userRepo.LoginExists(context.Background(), user.Login)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = userRepo.LoginExists(context.Background(), uuid.New().String())
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	created, err := getUser(t, &user)
+	assert.NoError(t, err)
+	assert.Equal(t, user.TelegramID, created.TelegramID)
+}
+
+func TestUserRepo_Favorites(t *testing.T) {
+	user := model.User{
+		ID:          uuid.New().String(),
+		Name:        "test",
+		Login:       "test",
+		Password:    "test",
+		Description: "",
+	}
+
+	createdUser(t, &user)
+	defer deleteUser(t, &user)
+
+	errFirst := userRepo.AddFavorite(context.Background(), user.ID, "Minsk")
+	errSecond := userRepo.AddFavorite(context.Background(), user.ID, "Brest")
+	errDuplicate := userRepo.AddFavorite(context.Background(), user.ID, "minsk")
+	errUnknownUser := userRepo.AddFavorite(context.Background(), uuid.New().String(), "Minsk")
+
+	assert.NoError(t, errFirst)
+	assert.NoError(t, errSecond)
+	assert.NoError(t, errDuplicate)
+	assert.Error(t, errUnknownUser)
+
+	cities, err := userRepo.GetFavorites(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Minsk", "Brest"}, cities)
+
+	removed, err := userRepo.RemoveFavorite(context.Background(), user.ID, "MINSK")
+	assert.NoError(t, err)
+	assert.True(t, removed)
+
+	removed, err = userRepo.RemoveFavorite(context.Background(), user.ID, "Minsk")
+	assert.NoError(t, err)
+	assert.False(t, removed)
+
+	cities, err = userRepo.GetFavorites(context.Background(), user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Brest"}, cities)
+}
+
 func createdUser(t *testing.T, user *model.User) {
 	err := userRepo.CreateUser(context.Background(), *user)
 	assert.NoError(t, err)
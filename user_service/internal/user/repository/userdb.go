@@ -8,4 +8,5 @@ type user struct {
 	Login       string         `db:"login"`
 	Password    string         `db:"password"`
 	Description sql.NullString `db:"description"`
+	TelegramID  sql.NullInt64  `db:"telegram_id"`
 }
@@ -7,3 +7,11 @@ type UserDto struct {
 	Password    string `json:"password"`
 	Description string `json:"description"`
 }
+
+type FavoriteDto struct {
+	City string `json:"city"`
+}
+
+type FavoritesDto struct {
+	Cities []string `json:"cities"`
+}
@@ -19,4 +19,8 @@ func (s *Server) RegisterRoutes() {
 	e.DELETE("/:userID", s.DeleteUser)
 	e.GET("", s.GetAllUsers)
 
+	e.GET("/:userID/favorites", s.GetFavorites)
+	e.POST("/:userID/favorites", s.AddFavorite)
+	e.DELETE("/:userID/favorites/:city", s.RemoveFavorite)
+
 }
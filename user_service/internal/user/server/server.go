@@ -1,20 +1,25 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"github.com/jinzhu/copier"
 	"github.com/labstack/echo"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"net"
 	"net/http"
+	"time"
 	"user_service/api/pb"
 	"user_service/internal/config"
 	"user_service/internal/errorstore"
 	"user_service/internal/user/converter"
 	"user_service/internal/user/model"
 	"user_service/internal/user/server/dto"
+	"user_service/internal/user/service"
 )
 
 //go:generate mockgen -source ./server.go -destination ../mock/server.go -package mock
@@ -26,7 +31,16 @@ type controller interface {
 	DeleteUser(ctx context.Context, id string) error
 	GetAllUsers(ctx context.Context) ([]model.User, error)
 	Authorize(ctx context.Context, login, password string) (string, error)
+	Favorites(ctx context.Context, userID string) ([]string, error)
+	SaveFavorite(ctx context.Context, userID, city string) ([]string, error)
+	DeleteFavorite(ctx context.Context, userID, city string) ([]string, error)
 	Get(ctx context.Context, req *pb.Request) (*pb.Response, error)
+	Login(ctx context.Context, req *pb.Request) (*pb.LoginResponse, error)
+	Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.LoginResponse, error)
+	CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.LoginResponse, error)
+	GetFavorites(ctx context.Context, req *pb.FavoritesRequest) (*pb.FavoritesResponse, error)
+	AddFavorite(ctx context.Context, req *pb.FavoriteRequest) (*pb.FavoritesResponse, error)
+	RemoveFavorite(ctx context.Context, req *pb.FavoriteRequest) (*pb.FavoritesResponse, error)
 }
 
 type Server struct {
@@ -36,6 +50,7 @@ type Server struct {
 	c         controller
 	cfg       *config.Config
 	client    *grpc.Server
+	health    *health.Server
 }
 
 func NewServer(listenURI string, r *echo.Echo, logger *logrus.Logger, c controller, cfg *config.Config) *Server {
@@ -46,11 +61,39 @@ func NewServer(listenURI string, r *echo.Echo, logger *logrus.Logger, c controll
 		c:         c,
 		cfg:       cfg,
 		client:    grpc.NewServer(),
+		health:    health.NewServer(),
 	}
 }
 
 func (s *Server) Register() {
 	s.client.RegisterService(&pb.UserService_ServiceDesc, s.c)
+	grpc_health_v1.RegisterHealthServer(s.client, s.health)
+}
+
+// WatchHealth reports the service as not serving while check fails. It runs
+// check every interval until ctx is done.
+func (s *Server) WatchHealth(ctx context.Context, interval time.Duration, check func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		err := check(checkCtx)
+		cancel()
+
+		serving := grpc_health_v1.HealthCheckResponse_SERVING
+		if err != nil {
+			s.logger.Warning("health check failed: ", err)
+			serving = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		}
+		s.health.SetServingStatus("", serving)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 func (s *Server) StartGRPC() {
 	l, err := net.Listen("tcp", "localhost:8085")
@@ -162,3 +205,41 @@ func (s *Server) Authorize(ctx echo.Context) error {
 	}
 	return ctx.JSON(http.StatusOK, fmt.Sprintf("Here is your token: %s", token))
 }
+
+func (s *Server) GetFavorites(ctx echo.Context) error {
+	cities, err := s.c.Favorites(ctx.Request().Context(), ctx.Param("userID"))
+	if err != nil {
+		s.logger.Info("could not get favorites:", err)
+		return echo.NewHTTPError(http.StatusNotFound, errorstore.EntityNotFound(err))
+	}
+	return ctx.JSON(http.StatusOK, dto.FavoritesDto{Cities: cities})
+}
+
+func (s *Server) AddFavorite(ctx echo.Context) error {
+	var favoriteDto dto.FavoriteDto
+	err := ctx.Bind(&favoriteDto)
+	if err != nil {
+		s.logger.Info("could not decode data:", err)
+		return echo.NewHTTPError(http.StatusBadRequest, errorstore.BadRequest(err))
+	}
+
+	cities, err := s.c.SaveFavorite(ctx.Request().Context(), ctx.Param("userID"), favoriteDto.City)
+	if errors.Is(err, service.ErrInvalidFavorite) {
+		s.logger.Info("could not add favorite:", err)
+		return echo.NewHTTPError(http.StatusBadRequest, errorstore.BadRequest(err))
+	}
+	if err != nil {
+		s.logger.Info("could not add favorite:", err)
+		return echo.NewHTTPError(http.StatusNotFound, errorstore.EntityNotFound(err))
+	}
+	return ctx.JSON(http.StatusCreated, dto.FavoritesDto{Cities: cities})
+}
+
+func (s *Server) RemoveFavorite(ctx echo.Context) error {
+	cities, err := s.c.DeleteFavorite(ctx.Request().Context(), ctx.Param("userID"), ctx.Param("city"))
+	if err != nil {
+		s.logger.Info("could not remove favorite:", err)
+		return echo.NewHTTPError(http.StatusNotFound, errorstore.EntityNotFound(err))
+	}
+	return ctx.JSON(http.StatusOK, dto.FavoritesDto{Cities: cities})
+}
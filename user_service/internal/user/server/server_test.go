@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -20,6 +21,7 @@ import (
 	"user_service/internal/config"
 	"user_service/internal/user/mock"
 	"user_service/internal/user/model"
+	"user_service/internal/user/service"
 )
 
 type server struct {
@@ -321,3 +323,104 @@ func TestServer_DeleteUser(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_Favorites(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Method string
+		Path   string
+		Body   string
+		Status int
+	}{
+		{Name: "Success to get favorites", Method: echo.GET, Path: "/favorites", Status: http.StatusOK},
+		{Name: "Success to add favorite", Method: echo.POST, Path: "/favorites", Body: `{"city":"Brest"}`,
+			Status: http.StatusCreated},
+		{Name: "Failed to add empty favorite", Method: echo.POST, Path: "/favorites", Body: `{"city":""}`,
+			Status: http.StatusBadRequest},
+		{Name: "Success to remove favorite", Method: echo.DELETE, Path: "/favorites/Minsk", Status: http.StatusOK},
+		{Name: "Failed to remove unknown favorite", Method: echo.DELETE, Path: "/favorites/Brest",
+			Status: http.StatusNotFound},
+	}
+
+	ctrl := gomock.NewController(t)
+	mockController := mock.NewMockcontroller(ctrl)
+
+	mockController.EXPECT().Favorites(gomock.Any(), firstValidUser.ID).Return([]string{"Minsk"}, nil).AnyTimes()
+	mockController.EXPECT().SaveFavorite(gomock.Any(), firstValidUser.ID, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, userID, city string) ([]string, error) {
+			if city == emptyData {
+				return nil, service.ErrInvalidFavorite
+			}
+			return []string{"Minsk", city}, nil
+		}).AnyTimes()
+	mockController.EXPECT().DeleteFavorite(gomock.Any(), firstValidUser.ID, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, userID, city string) ([]string, error) {
+			if city != "Minsk" {
+				return nil, service.ErrFavoriteNotFound
+			}
+			return []string{}, nil
+		}).AnyTimes()
+
+	keyword := "test"
+
+	s := NewServer("", echo.New(), logrus.New(), mockController, &config.Config{JWTKeyword: keyword})
+	s.RegisterRoutes()
+
+	srv := httptest.NewServer(s.r)
+	defer srv.Close()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    firstValidUser.ID,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		ID:        firstValidUser.ID,
+	})
+	tokenString, err := token.SignedString([]byte(keyword))
+	require.NoError(t, err)
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			url := fmt.Sprintf("%s/user/%s%s", srv.URL, firstValidUser.ID, test.Path)
+			req, err := http.NewRequest(test.Method, url, strings.NewReader(test.Body))
+			require.NoError(t, err)
+
+			req.Header.Set("Authorization", tokenString)
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+			client := http.Client{
+				Timeout: time.Second,
+			}
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, test.Status, resp.StatusCode)
+		})
+	}
+}
+
+func TestServer_WatchHealth(t *testing.T) {
+	var useCase = []struct {
+		Name    string
+		Err     error
+		Serving grpc_health_v1.HealthCheckResponse_ServingStatus
+	}{
+		{Name: "Database is up", Err: nil, Serving: grpc_health_v1.HealthCheckResponse_SERVING},
+		{Name: "Database is down", Err: errors.New("connection refused"), Serving: grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+	}
+
+	s := NewServer("", echo.New(), logrus.New(), nil, &config.Config{})
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			s.WatchHealth(ctx, time.Minute, func(context.Context) error { return us.Err })
+
+			res, err := s.health.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+			assert.NoError(t, err)
+			assert.Equal(t, us.Serving, res.GetStatus())
+		})
+	}
+}
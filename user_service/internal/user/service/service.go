@@ -2,10 +2,16 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"regexp"
+	"strings"
 	"time"
 	"user_service/api/pb"
 	"user_service/internal/config"
@@ -14,6 +20,20 @@ import (
 
 //go:generate mockgen -source ./service.go -destination ../mock/service.go -package mock
 
+const (
+	tokenTTL     = 1 * time.Hour
+	maxFavorites = 10
+	minPassword  = 8
+)
+
+// loginPattern is what logins of accounts created over gRPC must match.
+var loginPattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,32}$`)
+
+var (
+	ErrFavoriteNotFound = errors.New("favorite not found")
+	ErrInvalidFavorite  = errors.New("invalid favorite")
+)
+
 type repository interface {
 	CreateUser(ctx context.Context, user model.User) error
 	GetUser(ctx context.Context, id string) (model.User, error)
@@ -21,6 +41,10 @@ type repository interface {
 	DeleteUser(ctx context.Context, id string) error
 	GetAllUsers(ctx context.Context) ([]model.User, error)
 	CheckAuth(ctx context.Context, login, password string) (model.User, error)
+	LoginExists(ctx context.Context, login string) (bool, error)
+	GetFavorites(ctx context.Context, userID string) ([]string, error)
+	AddFavorite(ctx context.Context, userID, city string) error
+	RemoveFavorite(ctx context.Context, userID, city string) (bool, error)
 }
 
 type Controller struct {
@@ -73,13 +97,83 @@ func (c *Controller) Authorize(ctx context.Context, login, password string) (str
 		return "", fmt.Errorf("failed to authorize user: %w", err)
 	}
 
+	tokenString, _, err := c.issueToken(user)
+	if err != nil {
+		return "", err
+	}
+	return tokenString, nil
+}
+
+// Favorites returns the user's favorite cities.
+func (c *Controller) Favorites(ctx context.Context, userID string) ([]string, error) {
+	_, err := c.repo.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return c.repo.GetFavorites(ctx, userID)
+}
+
+// SaveFavorite adds the city to the user's favorites and returns them all.
+func (c *Controller) SaveFavorite(ctx context.Context, userID, city string) ([]string, error) {
+	city = strings.TrimSpace(city)
+	if city == "" {
+		return nil, fmt.Errorf("%w: city is a vital field", ErrInvalidFavorite)
+	}
+
+	cities, err := c.Favorites(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, fav := range cities {
+		if strings.EqualFold(fav, city) {
+			return cities, nil
+		}
+	}
+	if len(cities) >= maxFavorites {
+		return nil, fmt.Errorf("%w: there can be at most %d favorites", ErrInvalidFavorite, maxFavorites)
+	}
+
+	err = c.repo.AddFavorite(ctx, userID, city)
+	if err != nil {
+		return nil, err
+	}
+	return append(cities, city), nil
+}
+
+// DeleteFavorite removes the city from the user's favorites and returns the
+// rest.
+func (c *Controller) DeleteFavorite(ctx context.Context, userID, city string) ([]string, error) {
+	ok, err := c.repo.RemoveFavorite(ctx, userID, strings.TrimSpace(city))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrFavoriteNotFound
+	}
+	return c.repo.GetFavorites(ctx, userID)
+}
+
+// ParseToken validates a token issued by this service and returns the user ID.
+func (c *Controller) ParseToken(tokenString string) (string, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(c.cfg.JWTKeyword), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse token: %w", err)
+	}
+	return claims.ID, nil
+}
+
+func (c *Controller) issueToken(user model.User) (string, time.Time, error) {
 	now := time.Now()
+	expiresAt := now.Add(tokenTTL)
 
 	claims := jwt.RegisteredClaims{
 		Issuer:    user.ID,
 		Subject:   "authorized",
 		Audience:  nil,
-		ExpiresAt: jwt.NewNumericDate(now.Add(1 * time.Hour)),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
 		NotBefore: jwt.NewNumericDate(now),
 		IssuedAt:  jwt.NewNumericDate(now),
 		ID:        user.ID,
@@ -88,9 +182,9 @@ func (c *Controller) Authorize(ctx context.Context, login, password string) (str
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenString, err := token.SignedString([]byte(c.cfg.JWTKeyword))
 	if err != nil {
-		return "", fmt.Errorf("failed to sigh token: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to sigh token: %w", err)
 	}
-	return tokenString, nil
+	return tokenString, expiresAt, nil
 }
 
 //gPRC
@@ -102,3 +196,162 @@ func (c *Controller) Get(ctx context.Context, req *pb.Request) (*pb.Response, er
 	}
 	return &pb.Response{Response: true}, nil
 }
+
+func (c *Controller) Login(ctx context.Context, req *pb.Request) (*pb.LoginResponse, error) {
+	user, err := c.repo.CheckAuth(ctx, req.GetLogin(), req.GetPassword())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.Unauthenticated, "wrong login or password")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return c.loginResponse(user)
+}
+
+func (c *Controller) Refresh(ctx context.Context, req *pb.RefreshRequest) (*pb.LoginResponse, error) {
+	id, err := c.ParseToken(req.GetToken())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	user, err := c.repo.GetUser(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Error(codes.Unauthenticated, "user no longer exists")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return c.loginResponse(user)
+}
+
+// CreateUser registers an account for a chat and logs it in. Unlike Create it
+// checks the credentials, as the bot lets anyone register.
+func (c *Controller) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.LoginResponse, error) {
+	user := model.User{
+		Name:       strings.TrimSpace(req.GetName()),
+		Login:      req.GetLogin(),
+		Password:   req.GetPassword(),
+		TelegramID: req.GetTelegramId(),
+	}
+
+	err := validateAccount(user)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	exists, err := c.repo.LoginExists(ctx, user.Login)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if exists {
+		return nil, status.Error(codes.AlreadyExists, "login is already taken")
+	}
+
+	// The check above is only a shortcut: a concurrent registration of the
+	// same login is caught by the unique index.
+	err = c.Create(ctx, &user)
+	if errors.Is(err, model.ErrLoginTaken) {
+		return nil, status.Error(codes.AlreadyExists, "login is already taken")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return c.loginResponse(user)
+}
+
+func validateAccount(user model.User) error {
+	switch {
+	case user.Name == "":
+		return errors.New("name is a vital field")
+	case !loginPattern.MatchString(user.Login):
+		return errors.New("login must be 3 to 32 letters, digits, dots, dashes or underscores")
+	case len([]rune(user.Password)) < minPassword:
+		return fmt.Errorf("password must be at least %d characters long", minPassword)
+	case strings.ContainsAny(user.Password, " \t\n"):
+		return errors.New("password must not contain spaces")
+	}
+	return nil
+}
+
+func (c *Controller) loginResponse(user model.User) (*pb.LoginResponse, error) {
+	token, expiresAt, err := c.issueToken(user)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt.Unix(),
+		User: &pb.User{
+			Id:          user.ID,
+			Name:        user.Name,
+			Login:       user.Login,
+			Description: user.Description,
+		},
+	}, nil
+}
+
+func (c *Controller) GetFavorites(ctx context.Context, _ *pb.FavoritesRequest) (*pb.FavoritesResponse, error) {
+	userID, err := c.tokenUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cities, err := c.Favorites(ctx, userID)
+	if err != nil {
+		return nil, favoritesError(err)
+	}
+	return &pb.FavoritesResponse{Cities: cities}, nil
+}
+
+func (c *Controller) AddFavorite(ctx context.Context, req *pb.FavoriteRequest) (*pb.FavoritesResponse, error) {
+	userID, err := c.tokenUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cities, err := c.SaveFavorite(ctx, userID, req.GetCity())
+	if err != nil {
+		return nil, favoritesError(err)
+	}
+	return &pb.FavoritesResponse{Cities: cities}, nil
+}
+
+func (c *Controller) RemoveFavorite(ctx context.Context, req *pb.FavoriteRequest) (*pb.FavoritesResponse, error) {
+	userID, err := c.tokenUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cities, err := c.DeleteFavorite(ctx, userID, req.GetCity())
+	if err != nil {
+		return nil, favoritesError(err)
+	}
+	return &pb.FavoritesResponse{Cities: cities}, nil
+}
+
+// tokenUser returns the ID of the user whose token is in the "authorization"
+// metadata.
+func (c *Controller) tokenUser(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+		return "", status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+
+	id, err := c.ParseToken(strings.TrimPrefix(values[0], "Bearer "))
+	if err != nil {
+		return "", status.Error(codes.Unauthenticated, err.Error())
+	}
+	return id, nil
+}
+
+func favoritesError(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidFavorite):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrFavoriteNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
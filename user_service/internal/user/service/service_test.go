@@ -2,12 +2,19 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"os"
 	"testing"
+	"time"
+	"user_service/api/pb"
 	"user_service/internal/config"
 	mock_user "user_service/internal/user/mock"
 	"user_service/internal/user/model"
@@ -334,3 +341,269 @@ func TestController_Authorize(t *testing.T) {
 		})
 	}
 }
+
+func TestController_Login(t *testing.T) {
+	m := map[string]model.User{firstValidUser.Login: firstValidUser, secondValidUser.Login: secondValidUser}
+
+	var useCase = []struct {
+		Name      string
+		UserInput model.User
+		Code      codes.Code
+	}{
+		{Name: "Failed to log in user", UserInput: userEmpty, Code: codes.Unauthenticated},
+		{Name: "Failed to log in with wrong password",
+			UserInput: model.User{Login: firstValidUser.Login, Password: "wrong"}, Code: codes.Unauthenticated},
+		{Name: "Failed to reach database", UserInput: model.User{Login: "broken"}, Code: codes.Internal},
+		{Name: "Success to log in user#1", UserInput: firstValidUser, Code: codes.OK},
+		{Name: "Success to log in user#2", UserInput: secondValidUser, Code: codes.OK},
+	}
+
+	mockCtrl := gomock.NewController(t)
+	userRepoMock := mock_user.NewMockrepository(mockCtrl)
+	cfg := config.Config{JWTKeyword: "keyword"}
+
+	userRepoMock.EXPECT().CheckAuth(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, login, password string) (model.User, error) {
+			if login == "broken" {
+				return model.User{}, errors.New("connection refused")
+			}
+			user, ok := m[login]
+			if !ok || user.Password != password {
+				return model.User{}, fmt.Errorf("failed to scan user: %w", sql.ErrNoRows)
+			}
+			return user, nil
+		}).AnyTimes()
+
+	srv := NewController(userRepoMock, &cfg)
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			res, err := srv.Login(context.Background(), &pb.Request{Login: us.UserInput.Login, Password: us.UserInput.Password})
+			if us.Code != codes.OK {
+				assert.Equal(t, us.Code, status.Code(err))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, us.UserInput.ID, res.GetUser().GetId())
+				assert.Equal(t, us.UserInput.Name, res.GetUser().GetName())
+				assert.Greater(t, res.GetExpiresAt(), time.Now().Unix())
+
+				id, err := srv.ParseToken(res.GetToken())
+				assert.NoError(t, err)
+				assert.Equal(t, us.UserInput.ID, id)
+			}
+		})
+	}
+}
+
+func TestController_Refresh(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	userRepoMock := mock_user.NewMockrepository(mockCtrl)
+	cfg := config.Config{JWTKeyword: "keyword"}
+
+	userRepoMock.EXPECT().GetUser(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, id string) (model.User, error) {
+			switch id {
+			case firstValidUser.ID:
+				return firstValidUser, nil
+			case secondValidUser.ID:
+				return model.User{}, fmt.Errorf("failed to scan user: %w", sql.ErrNoRows)
+			}
+			return model.User{}, errors.New("connection refused")
+		}).AnyTimes()
+
+	srv := NewController(userRepoMock, &cfg)
+
+	valid, _, err := srv.issueToken(firstValidUser)
+	assert.NoError(t, err)
+	deleted, _, err := srv.issueToken(secondValidUser)
+	assert.NoError(t, err)
+	unreachable, _, err := srv.issueToken(model.User{ID: "unreachable"})
+	assert.NoError(t, err)
+	foreign, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{ID: firstValidUser.ID}).
+		SignedString([]byte("other keyword"))
+	assert.NoError(t, err)
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		ID:        firstValidUser.ID,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+	}).SignedString([]byte(cfg.JWTKeyword))
+	assert.NoError(t, err)
+
+	var useCase = []struct {
+		Name  string
+		Token string
+		Code  codes.Code
+	}{
+		{Name: "Success to refresh token", Token: valid, Code: codes.OK},
+		{Name: "Failed to refresh expired token", Token: expired, Code: codes.Unauthenticated},
+		{Name: "Failed to refresh token signed by someone else", Token: foreign, Code: codes.Unauthenticated},
+		{Name: "Failed to refresh token of deleted user", Token: deleted, Code: codes.Unauthenticated},
+		{Name: "Failed to refresh while the database is down", Token: unreachable, Code: codes.Internal},
+		{Name: "Failed to refresh garbage", Token: "garbage", Code: codes.Unauthenticated},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			res, err := srv.Refresh(context.Background(), &pb.RefreshRequest{Token: us.Token})
+			assert.Equal(t, us.Code, status.Code(err))
+			if us.Code == codes.OK {
+				assert.NoError(t, err)
+				assert.Equal(t, firstValidUser.ID, res.GetUser().GetId())
+				assert.NotEmpty(t, res.GetToken())
+			}
+		})
+	}
+}
+
+func TestController_SaveFavorite(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	userRepoMock := mock_user.NewMockrepository(mockCtrl)
+	cfg := config.Config{}
+
+	full := make([]string, maxFavorites)
+	for i := range full {
+		full[i] = fmt.Sprintf("city %d", i)
+	}
+	favorites := map[string][]string{firstValidUser.ID: {"Minsk"}, secondValidUser.ID: full}
+
+	userRepoMock.EXPECT().GetUser(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, id string) (model.User, error) {
+			if _, ok := favorites[id]; !ok {
+				return model.User{}, errors.New("entity not found")
+			}
+			return model.User{ID: id}, nil
+		}).AnyTimes()
+	userRepoMock.EXPECT().GetFavorites(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, id string) ([]string, error) {
+			return favorites[id], nil
+		}).AnyTimes()
+	userRepoMock.EXPECT().AddFavorite(gomock.Any(), firstValidUser.ID, "Brest").Return(nil).Times(1)
+
+	var useCase = []struct {
+		Name      string
+		UserID    string
+		City      string
+		Favorites []string
+		IsError   bool
+	}{
+		{Name: "Success to add favorite", UserID: firstValidUser.ID, City: " Brest ", Favorites: []string{"Minsk", "Brest"}},
+		{Name: "Success to add favorite twice", UserID: firstValidUser.ID, City: "minsk", Favorites: []string{"Minsk"}},
+		{Name: "Failed to add empty favorite", UserID: firstValidUser.ID, City: " ", IsError: true},
+		{Name: "Failed to add too many favorites", UserID: secondValidUser.ID, City: "Brest", IsError: true},
+		{Name: "Failed to add favorite of unknown user", UserID: "unknown", City: "Brest", IsError: true},
+	}
+
+	srv := NewController(userRepoMock, &cfg)
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			cities, err := srv.SaveFavorite(context.Background(), us.UserID, us.City)
+			if us.IsError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, us.Favorites, cities)
+			}
+		})
+	}
+}
+
+func TestController_RemoveFavorite(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	userRepoMock := mock_user.NewMockrepository(mockCtrl)
+	cfg := config.Config{JWTKeyword: "keyword"}
+
+	userRepoMock.EXPECT().RemoveFavorite(gomock.Any(), firstValidUser.ID, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, id, city string) (bool, error) {
+			return city == "Minsk", nil
+		}).AnyTimes()
+	userRepoMock.EXPECT().GetFavorites(gomock.Any(), firstValidUser.ID).Return([]string{"Brest"}, nil).AnyTimes()
+
+	srv := NewController(userRepoMock, &cfg)
+
+	valid, _, err := srv.issueToken(firstValidUser)
+	assert.NoError(t, err)
+
+	var useCase = []struct {
+		Name  string
+		Token string
+		City  string
+		Code  codes.Code
+	}{
+		{Name: "Success to remove favorite", Token: "Bearer " + valid, City: "Minsk", Code: codes.OK},
+		{Name: "Failed to remove unknown favorite", Token: "Bearer " + valid, City: "Pinsk", Code: codes.NotFound},
+		{Name: "Failed to remove favorite without bearer", Token: valid, City: "Minsk", Code: codes.Unauthenticated},
+		{Name: "Failed to remove favorite with garbage", Token: "Bearer garbage", City: "Minsk", Code: codes.Unauthenticated},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", us.Token))
+			res, err := srv.RemoveFavorite(ctx, &pb.FavoriteRequest{City: us.City})
+			assert.Equal(t, us.Code, status.Code(err))
+			if us.Code == codes.OK {
+				assert.Equal(t, []string{"Brest"}, res.GetCities())
+			}
+		})
+	}
+
+	_, err = srv.GetFavorites(context.Background(), &pb.FavoritesRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err), "no metadata at all")
+}
+
+func TestController_CreateUser(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	userRepoMock := mock_user.NewMockrepository(mockCtrl)
+	cfg := config.Config{JWTKeyword: "keyword"}
+
+	userRepoMock.EXPECT().LoginExists(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, login string) (bool, error) {
+			return login == firstValidUser.Login, nil
+		}).AnyTimes()
+	userRepoMock.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, user model.User) error {
+			assert.NotEmpty(t, user.ID)
+			assert.Equal(t, int64(42), user.TelegramID)
+			if user.Login == "raced_user" {
+				return model.ErrLoginTaken
+			}
+			return nil
+		}).Times(2)
+
+	var useCase = []struct {
+		Name    string
+		Request *pb.CreateUserRequest
+		Code    codes.Code
+	}{
+		{Name: "Success to create user", Code: codes.OK,
+			Request: &pb.CreateUserRequest{Name: " New ", Login: "new_user", Password: "password", TelegramId: 42}},
+		{Name: "Failed to create user without name", Code: codes.InvalidArgument,
+			Request: &pb.CreateUserRequest{Name: " ", Login: "new_user", Password: "password"}},
+		{Name: "Failed to create user with short login", Code: codes.InvalidArgument,
+			Request: &pb.CreateUserRequest{Name: "New", Login: "nu", Password: "password"}},
+		{Name: "Failed to create user with spaces in login", Code: codes.InvalidArgument,
+			Request: &pb.CreateUserRequest{Name: "New", Login: "new user", Password: "password"}},
+		{Name: "Failed to create user with short password", Code: codes.InvalidArgument,
+			Request: &pb.CreateUserRequest{Name: "New", Login: "new_user", Password: "short"}},
+		{Name: "Failed to create user with taken login", Code: codes.AlreadyExists,
+			Request: &pb.CreateUserRequest{Name: "New", Login: firstValidUser.Login, Password: "password"}},
+		{Name: "Failed to create user registered concurrently", Code: codes.AlreadyExists,
+			Request: &pb.CreateUserRequest{Name: "New", Login: "raced_user", Password: "password", TelegramId: 42}},
+	}
+
+	srv := NewController(userRepoMock, &cfg)
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			res, err := srv.CreateUser(context.Background(), us.Request)
+			assert.Equal(t, us.Code, status.Code(err))
+			if us.Code == codes.OK {
+				assert.Equal(t, "New", res.GetUser().GetName())
+				assert.Equal(t, us.Request.Login, res.GetUser().GetLogin())
+
+				id, err := srv.ParseToken(res.GetToken())
+				assert.NoError(t, err)
+				assert.Equal(t, res.GetUser().GetId(), id)
+			}
+		})
+	}
+}
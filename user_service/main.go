@@ -1,18 +1,23 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
+	"time"
 	"user_service/internal/config"
 	"user_service/internal/user/repository"
 	"user_service/internal/user/server"
 	"user_service/internal/user/service"
 )
 
+// healthInterval is how often the database is pinged for health checks.
+const healthInterval = 15 * time.Second
+
 func main() {
 
 	logger := logrus.New()
@@ -49,6 +54,7 @@ func main() {
 	srv := server.NewServer(cfg.Port, echo.New(), logger, controller, cfg)
 
 	srv.Register()
+	go srv.WatchHealth(context.Background(), healthInterval, db.PingContext)
 
 	srv.RegisterRoutes()
 
@@ -0,0 +1,57 @@
+package chart
+
+import (
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"math"
+)
+
+const (
+	arrowLength = 14
+	headLength  = 5
+	headAngle   = 25 * math.Pi / 180
+)
+
+// arrows is a plotter drawing wind arrows centred on each point and pointing
+// where the wind blows to.
+type arrows struct {
+	xys     plotter.XYs
+	degrees []float64
+	style   draw.LineStyle
+}
+
+func (a arrows) Plot(c draw.Canvas, plt *plot.Plot) {
+	trX, trY := plt.Transforms(&c)
+
+	for i, xy := range a.xys {
+		// Wind direction is where the wind comes from, so the arrow is reversed.
+		angle := (a.degrees[i] + 180) * math.Pi / 180
+		center := vg.Point{X: trX(xy.X), Y: trY(xy.Y)}
+		half := vg.Point{
+			X: vg.Length(math.Sin(angle)) * arrowLength / 2,
+			Y: vg.Length(math.Cos(angle)) * arrowLength / 2,
+		}
+
+		tail := center.Sub(half)
+		head := center.Add(half)
+		if !c.Contains(tail) || !c.Contains(head) {
+			continue
+		}
+
+		c.StrokeLine2(a.style, tail.X, tail.Y, head.X, head.Y)
+		for _, side := range []float64{-1, 1} {
+			barb := angle + math.Pi + side*headAngle
+			end := head.Add(vg.Point{
+				X: vg.Length(math.Sin(barb)) * headLength,
+				Y: vg.Length(math.Cos(barb)) * headLength,
+			})
+			c.StrokeLine2(a.style, head.X, head.Y, end.X, end.Y)
+		}
+	}
+}
+
+func (a arrows) DataRange() (xmin, xmax, ymin, ymax float64) {
+	return plotter.XYRange(a.xys)
+}
@@ -0,0 +1,218 @@
+package chart
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+	"image/color"
+	"math"
+	"time"
+)
+
+const (
+	Width  = 8 * vg.Inch
+	Height = 6 * vg.Inch
+	DPI    = 96
+
+	labelEvery = 6
+	arrowEvery = 3
+)
+
+var (
+	tempColor   = color.RGBA{R: 220, G: 60, B: 40, A: 255}
+	precipColor = color.RGBA{R: 50, G: 110, B: 220, A: 255}
+	windColor   = color.RGBA{R: 60, G: 60, B: 60, A: 255}
+)
+
+var ErrNoData = errors.New("no forecast points to render")
+
+// Point is one hour of forecast. Temperature is in °C, precipitation in mm and
+// wind speed in m/s with the meteorological direction it blows from.
+type Point struct {
+	Time          time.Time
+	Temp          float64
+	Precipitation float64
+	WindSpeed     float64
+	WindDeg       float64
+}
+
+// Render draws temperature, precipitation and wind panels for hourly points,
+// in time order, sharing the time axis and returns the PNG image.
+func Render(title string, points []Point) ([]byte, error) {
+	if len(points) == 0 {
+		return nil, ErrNoData
+	}
+
+	start := points[0].Time
+	ticks := hourTicker(start)
+
+	temp, err := temperaturePlot(title, points, start, ticks)
+	if err != nil {
+		return nil, err
+	}
+	precip, err := precipitationPlot(points, start, ticks)
+	if err != nil {
+		return nil, err
+	}
+	wind, err := windPlot(points, start, ticks)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the x ranges identical so the panels line up hour by hour.
+	last := points[len(points)-1].Time.Sub(start).Hours()
+	for _, p := range []*plot.Plot{temp, precip, wind} {
+		p.X.Min = -0.5
+		p.X.Max = last + 0.5
+	}
+
+	img := vgimg.NewWith(vgimg.UseWH(Width, Height), vgimg.UseDPI(DPI))
+	dc := draw.New(img)
+	dc.SetColor(color.White)
+	dc.Fill(dc.Rectangle.Path())
+
+	tiles := draw.Tiles{
+		Rows:      3,
+		Cols:      1,
+		PadX:      vg.Millimeter,
+		PadY:      2 * vg.Millimeter,
+		PadTop:    2 * vg.Millimeter,
+		PadBottom: 2 * vg.Millimeter,
+		PadLeft:   2 * vg.Millimeter,
+		PadRight:  4 * vg.Millimeter,
+	}
+
+	plots := [][]*plot.Plot{{temp}, {precip}, {wind}}
+	canvases := plot.Align(plots, tiles, dc)
+	for i := range plots {
+		plots[i][0].Draw(canvases[i][0])
+	}
+
+	var buf bytes.Buffer
+	_, err = vgimg.PngCanvas{Canvas: img}.WriteTo(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func temperaturePlot(title string, points []Point, start time.Time, ticks plot.Ticker) (*plot.Plot, error) {
+	p := newPlot(ticks)
+	p.Title.Text = title
+	p.Y.Label.Text = "Temperature, °C"
+
+	line, err := plotter.NewLine(series(points, start, func(pt Point) float64 { return pt.Temp }))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build temperature line: %w", err)
+	}
+	line.Color = tempColor
+	line.Width = vg.Points(2)
+
+	p.Add(line)
+	return p, nil
+}
+
+func precipitationPlot(points []Point, start time.Time, ticks plot.Ticker) (*plot.Plot, error) {
+	p := newPlot(ticks)
+	p.Y.Label.Text = "Precipitation, mm"
+	// The axis spans at least 0..1 mm, so a drizzle does not fill the panel
+	// like a downpour. Add stretches it to fit heavier hours.
+	p.Y.Min = 0
+	p.Y.Max = 1
+
+	values := hourly(points)
+	bars, err := plotter.NewBarChart(values, barWidth(len(values)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build precipitation bars: %w", err)
+	}
+	// Bar i stands at XMin+i, so the first bar starts where the line series
+	// of the other panels do.
+	bars.XMin = points[0].Time.Sub(start).Hours()
+	bars.Color = precipColor
+	bars.LineStyle.Width = 0
+
+	p.Add(bars)
+	return p, nil
+}
+
+// hourly lays out the precipitation one value per hour from the first point,
+// so a missing hour leaves a gap instead of shifting the later bars.
+func hourly(points []Point) plotter.Values {
+	first := points[0].Time
+	values := make(plotter.Values, int(math.Round(points[len(points)-1].Time.Sub(first).Hours()))+1)
+	for _, pt := range points {
+		values[int(math.Round(pt.Time.Sub(first).Hours()))] += pt.Precipitation
+	}
+	return values
+}
+
+func windPlot(points []Point, start time.Time, ticks plot.Ticker) (*plot.Plot, error) {
+	p := newPlot(ticks)
+	p.Y.Label.Text = "Wind, m/s"
+	p.Y.Min = 0
+
+	speed := series(points, start, func(pt Point) float64 { return pt.WindSpeed })
+	line, err := plotter.NewLine(speed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build wind line: %w", err)
+	}
+	line.Color = windColor
+	line.Width = vg.Points(1)
+	line.Dashes = []vg.Length{vg.Points(3), vg.Points(3)}
+
+	arrows := arrows{style: draw.LineStyle{Color: windColor, Width: vg.Points(1.5)}}
+	for i := 0; i < len(points); i += arrowEvery {
+		arrows.xys = append(arrows.xys, speed[i])
+		arrows.degrees = append(arrows.degrees, points[i].WindDeg)
+	}
+
+	p.Add(line, arrows)
+	return p, nil
+}
+
+func newPlot(ticks plot.Ticker) *plot.Plot {
+	p := plot.New()
+	p.X.Tick.Marker = ticks
+	p.Add(plotter.NewGrid())
+	return p
+}
+
+func series(points []Point, start time.Time, value func(Point) float64) plotter.XYs {
+	xys := make(plotter.XYs, 0, len(points))
+	for _, pt := range points {
+		xys = append(xys, plotter.XY{X: pt.Time.Sub(start).Hours(), Y: value(pt)})
+	}
+	return xys
+}
+
+func barWidth(n int) vg.Length {
+	width := Width * 0.8 / vg.Length(n)
+	if width > vg.Points(12) {
+		return vg.Points(12)
+	}
+	return width
+}
+
+// hourTicker labels the x axis, measured in hours from start, with clock times.
+func hourTicker(start time.Time) plot.Ticker {
+	return plot.TickerFunc(func(min, max float64) []plot.Tick {
+		var ticks []plot.Tick
+		for h := 0; float64(h) <= max; h++ {
+			if float64(h) < min {
+				continue
+			}
+			tick := plot.Tick{Value: float64(h)}
+			t := start.Add(time.Duration(h) * time.Hour)
+			if t.Hour()%labelEvery == 0 {
+				tick.Label = t.Format("Mon 15:04")
+			}
+			ticks = append(ticks, tick)
+		}
+		return ticks
+	})
+}
@@ -0,0 +1,83 @@
+package chart
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/plot/plotter"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+)
+
+func forecast(hours int) []Point {
+	start := time.Date(2023, time.May, 10, 14, 0, 0, 0, time.UTC)
+	points := make([]Point, 0, hours)
+	for i := 0; i < hours; i++ {
+		points = append(points, Point{
+			Time:          start.Add(time.Duration(i) * time.Hour),
+			Temp:          12 + 6*math.Sin(float64(i)/24*2*math.Pi),
+			Precipitation: math.Max(0, math.Sin(float64(i)/5)),
+			WindSpeed:     3 + float64(i%7),
+			WindDeg:       float64(i * 15 % 360),
+		})
+	}
+	return points
+}
+
+func TestRender(t *testing.T) {
+	var useCase = []struct {
+		Name    string
+		Points  []Point
+		IsError bool
+	}{
+		{Name: "Failed to render without data", Points: nil, IsError: true},
+		{Name: "Success to render single hour", Points: forecast(1), IsError: false},
+		{Name: "Success to render 48 hours", Points: forecast(48), IsError: false},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			img, err := Render("Minsk", us.Points)
+			if us.IsError {
+				assert.ErrorIs(t, err, ErrNoData)
+				return
+			}
+
+			require.NoError(t, err)
+			cfg, err := png.DecodeConfig(bytes.NewReader(img))
+			require.NoError(t, err)
+			assert.Equal(t, int(Width.Dots(DPI)), cfg.Width)
+			assert.Equal(t, int(Height.Dots(DPI)), cfg.Height)
+		})
+	}
+}
+
+func TestHourly(t *testing.T) {
+	points := forecast(5)
+	points = append(points[:2], points[3:]...)
+	for i := range points {
+		points[i].Precipitation = float64(i + 1)
+	}
+
+	assert.Equal(t, plotter.Values{1, 2, 0, 3, 4}, hourly(points), "the missing hour stays empty")
+
+	_, err := Render("Minsk", points)
+	assert.NoError(t, err)
+}
+
+func TestHourTicker(t *testing.T) {
+	start := time.Date(2023, time.May, 10, 17, 0, 0, 0, time.UTC)
+
+	ticks := hourTicker(start).Ticks(0, 8)
+
+	require.Len(t, ticks, 9)
+	var labels []string
+	for _, tick := range ticks {
+		if tick.Label != "" {
+			labels = append(labels, tick.Label)
+		}
+	}
+	assert.Equal(t, []string{"Wed 18:00", "Thu 00:00"}, labels)
+}
@@ -0,0 +1,257 @@
+package climate
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxDistance is how far in km a location may be from a station to use its normals.
+const MaxDistance = 50
+
+var ErrNoStation = errors.New("no climate data for location")
+
+var header = []string{"city", "lat", "lon", "month", "mean", "mean_high", "mean_low", "record_high", "record_low"}
+
+type Month struct {
+	Mean       float64
+	MeanHigh   float64
+	MeanLow    float64
+	RecordHigh float64
+	RecordLow  float64
+}
+
+type Station struct {
+	City   string
+	Lat    float64
+	Lon    float64
+	Months [12]Month
+}
+
+// Normal is the expected climate of a station for a calendar day.
+type Normal struct {
+	Mean       float64
+	MeanHigh   float64
+	MeanLow    float64
+	RecordHigh float64
+	RecordLow  float64
+}
+
+type Comparison struct {
+	Station *Station
+	Date    time.Time
+	Temp    float64
+	Normal  Normal
+	Anomaly float64
+}
+
+type Dataset struct {
+	stations []*Station
+}
+
+// Load reads every *.csv file of dir. Each file holds rows in the header order,
+// one per station and month; lines starting with # are ignored.
+func Load(dir string) (*Dataset, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list climate files: %w", err)
+	}
+
+	d := &Dataset{}
+	for _, file := range files {
+		err = d.loadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", file, err)
+		}
+	}
+	return d, nil
+}
+
+func (d *Dataset) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.FieldsPerRecord = len(header)
+	r.TrimLeadingSpace = true
+
+	first, err := r.Read()
+	if err != nil {
+		return err
+	}
+	if strings.Join(first, ",") != strings.Join(header, ",") {
+		return fmt.Errorf("unexpected header %q", first)
+	}
+
+	stations := map[string]*Station{}
+	seen := map[string]int{}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		values, err := parseFloats(record[1:])
+		if err != nil {
+			return err
+		}
+
+		month := int(values[2])
+		if month < 1 || month > 12 {
+			return fmt.Errorf("invalid month %d for %s", month, record[0])
+		}
+
+		st, ok := stations[record[0]]
+		if !ok {
+			st = &Station{City: record[0], Lat: values[0], Lon: values[1]}
+			stations[record[0]] = st
+			d.stations = append(d.stations, st)
+		}
+		st.Months[month-1] = Month{
+			Mean:       values[3],
+			MeanHigh:   values[4],
+			MeanLow:    values[5],
+			RecordHigh: values[6],
+			RecordLow:  values[7],
+		}
+		seen[record[0]]++
+	}
+
+	for city, n := range seen {
+		if n != 12 {
+			return fmt.Errorf("station %s has %d months, expected 12", city, n)
+		}
+	}
+	return nil
+}
+
+func parseFloats(fields []string) ([]float64, error) {
+	values := make([]float64, 0, len(fields))
+	for _, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", field, err)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// Len returns the number of loaded stations.
+func (d *Dataset) Len() int {
+	return len(d.stations)
+}
+
+// Find returns the station named city or, failing that, the nearest station
+// within MaxDistance of the coordinates.
+func (d *Dataset) Find(city string, lat, lon float64) (*Station, error) {
+	for _, st := range d.stations {
+		if strings.EqualFold(st.City, city) {
+			return st, nil
+		}
+	}
+
+	var nearest *Station
+	best := math.Inf(1)
+	for _, st := range d.stations {
+		if dist := distance(lat, lon, st.Lat, st.Lon); dist < best {
+			nearest, best = st, dist
+		}
+	}
+	if nearest == nil || best > MaxDistance {
+		return nil, ErrNoStation
+	}
+	return nearest, nil
+}
+
+// Compare measures temp against the station normal for the date.
+func (d *Dataset) Compare(city string, lat, lon float64, date time.Time, temp float64) (Comparison, error) {
+	st, err := d.Find(city, lat, lon)
+	if err != nil {
+		return Comparison{}, err
+	}
+
+	normal := st.Normal(date)
+	return Comparison{
+		Station: st,
+		Date:    date,
+		Temp:    temp,
+		Normal:  normal,
+		Anomaly: temp - normal.Mean,
+	}, nil
+}
+
+// Normal interpolates the monthly means, taken as mid-month values, to the day.
+// Records are those of the month the date falls in.
+func (s *Station) Normal(date time.Time) Normal {
+	month := int(date.Month()) - 1
+	middle := time.Date(date.Year(), date.Month(), 15, 0, 0, 0, 0, date.Location())
+
+	other := month + 1
+	span := middle.AddDate(0, 1, 0).Sub(middle)
+	if date.Before(middle) {
+		other = month - 1
+		span = middle.Sub(middle.AddDate(0, -1, 0))
+	}
+	other = (other + 12) % 12
+
+	weight := math.Abs(date.Sub(middle).Hours()) / span.Hours()
+	current, next := s.Months[month], s.Months[other]
+
+	return Normal{
+		Mean:       lerp(current.Mean, next.Mean, weight),
+		MeanHigh:   lerp(current.MeanHigh, next.MeanHigh, weight),
+		MeanLow:    lerp(current.MeanLow, next.MeanLow, weight),
+		RecordHigh: current.RecordHigh,
+		RecordLow:  current.RecordLow,
+	}
+}
+
+// Summary renders the comparison, e.g. "+6.2 °C above normal".
+func (c Comparison) Summary() string {
+	var text string
+	switch {
+	case c.Temp > c.Normal.RecordHigh:
+		text = fmt.Sprintf("%+.1f °C above normal, beating the %s record", c.Anomaly, c.Date.Month())
+	case c.Temp < c.Normal.RecordLow:
+		text = fmt.Sprintf("%.1f °C below normal, beating the %s record", math.Abs(c.Anomaly), c.Date.Month())
+	case math.Abs(c.Anomaly) < 0.5:
+		text = "About normal"
+	case c.Anomaly > 0:
+		text = fmt.Sprintf("%+.1f °C above normal", c.Anomaly)
+	default:
+		text = fmt.Sprintf("%.1f °C below normal", math.Abs(c.Anomaly))
+	}
+	return fmt.Sprintf("%s (normal %.1f °C, records %.1f..%.1f °C)", text, c.Normal.Mean,
+		c.Normal.RecordLow, c.Normal.RecordHigh)
+}
+
+func lerp(a, b, weight float64) float64 {
+	return a + (b-a)*weight
+}
+
+// distance is the great-circle distance in km.
+func distance(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371
+
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
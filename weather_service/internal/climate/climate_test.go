@@ -0,0 +1,153 @@
+package climate
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const stationCSV = `# test data
+city,lat,lon,month,mean,mean_high,mean_low,record_high,record_low
+Testville,53.90,27.57,1,-4,-1,-7,10,-39
+Testville,53.90,27.57,2,-4,-1,-7,13,-35
+Testville,53.90,27.57,3,0,4,-3,19,-30
+Testville,53.90,27.57,4,7,12,2,28,-14
+Testville,53.90,27.57,5,13,18,7,31,-4
+Testville,53.90,27.57,6,17,22,11,33,0
+Testville,53.90,27.57,7,19,24,13,35,4
+Testville,53.90,27.57,8,17,23,12,36,1
+Testville,53.90,27.57,9,12,17,8,30,-4
+Testville,53.90,27.57,10,6,10,3,24,-15
+Testville,53.90,27.57,11,1,3,-1,16,-23
+Testville,53.90,27.57,12,-3,-1,-5,10,-32
+`
+
+func writeDataset(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	return dir
+}
+
+func loadTestDataset(t *testing.T) *Dataset {
+	d, err := Load(writeDataset(t, map[string]string{"test.csv": stationCSV}))
+	require.NoError(t, err)
+	return d
+}
+
+func TestLoad(t *testing.T) {
+	var useCase = []struct {
+		Name    string
+		Content string
+		IsError bool
+	}{
+		{Name: "Success to load station", Content: stationCSV, IsError: false},
+		{Name: "Failed on wrong header", Content: strings.Replace(stationCSV, "mean_high", "high", 1), IsError: true},
+		{Name: "Failed on missing month", Content: strings.Replace(stationCSV, "Testville,53.90,27.57,12,-3,-1,-5,10,-32\n", "", 1), IsError: true},
+		{Name: "Failed on bad number", Content: strings.Replace(stationCSV, ",35,4", ",hot,4", 1), IsError: true},
+		{Name: "Failed on bad month", Content: strings.Replace(stationCSV, ",53.90,27.57,12,", ",53.90,27.57,13,", 1), IsError: true},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			d, err := Load(writeDataset(t, map[string]string{"test.csv": us.Content}))
+			if us.IsError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, 1, d.Len())
+			}
+		})
+	}
+}
+
+func TestLoad_BundledData(t *testing.T) {
+	d, err := Load(filepath.Join("..", "..", "data", "climate"))
+	require.NoError(t, err)
+	assert.NotZero(t, d.Len())
+}
+
+func TestDataset_Find(t *testing.T) {
+	d := loadTestDataset(t)
+
+	var useCase = []struct {
+		Name    string
+		City    string
+		Lat     float64
+		Lon     float64
+		IsError bool
+	}{
+		{Name: "Found by name", City: "testville", IsError: false},
+		{Name: "Found by nearby coordinates", City: "Suburb", Lat: 53.95, Lon: 27.70, IsError: false},
+		{Name: "Too far away", City: "Elsewhere", Lat: 52.1, Lon: 23.7, IsError: true},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			st, err := d.Find(us.City, us.Lat, us.Lon)
+			if us.IsError {
+				assert.ErrorIs(t, err, ErrNoStation)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "Testville", st.City)
+			}
+		})
+	}
+}
+
+func TestStation_Normal(t *testing.T) {
+	st, err := loadTestDataset(t).Find("Testville", 0, 0)
+	require.NoError(t, err)
+
+	var useCase = []struct {
+		Name       string
+		Date       time.Time
+		Mean       float64
+		RecordHigh float64
+	}{
+		{Name: "Mid-month is the monthly mean", Date: time.Date(2023, time.July, 15, 0, 0, 0, 0, time.UTC), Mean: 19, RecordHigh: 35},
+		{Name: "Halfway to the next month", Date: time.Date(2023, time.April, 30, 0, 0, 0, 0, time.UTC), Mean: 10, RecordHigh: 28},
+		{Name: "Early month leans to previous", Date: time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC), Mean: -2, RecordHigh: 19},
+		{Name: "Wraps around the year", Date: time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC), Mean: -3.5, RecordHigh: 10},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			normal := st.Normal(us.Date)
+			assert.InDelta(t, us.Mean, normal.Mean, 0.1)
+			assert.Equal(t, us.RecordHigh, normal.RecordHigh)
+		})
+	}
+}
+
+func TestDataset_Compare(t *testing.T) {
+	d := loadTestDataset(t)
+	date := time.Date(2023, time.July, 15, 12, 0, 0, 0, time.UTC)
+
+	var useCase = []struct {
+		Name    string
+		Temp    float64
+		Anomaly float64
+		Summary string
+	}{
+		{Name: "Warmer than normal", Temp: 25.2, Anomaly: 6.2, Summary: "+6.2 °C above normal (normal 19.0 °C, records 4.0..35.0 °C)"},
+		{Name: "Colder than normal", Temp: 15, Anomaly: -4, Summary: "4.0 °C below normal (normal 19.0 °C, records 4.0..35.0 °C)"},
+		{Name: "About normal", Temp: 19.3, Anomaly: 0.3, Summary: "About normal (normal 19.0 °C, records 4.0..35.0 °C)"},
+		{Name: "Record heat", Temp: 36, Anomaly: 17, Summary: "+17.0 °C above normal, beating the July record (normal 19.0 °C, records 4.0..35.0 °C)"},
+		{Name: "Record cold", Temp: 3, Anomaly: -16, Summary: "16.0 °C below normal, beating the July record (normal 19.0 °C, records 4.0..35.0 °C)"},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			c, err := d.Compare("Testville", 0, 0, date, us.Temp)
+			require.NoError(t, err)
+			assert.InDelta(t, us.Anomaly, c.Anomaly, 0.1)
+			assert.Equal(t, us.Summary, c.Summary())
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package comfort
+
+type Tier int
+
+const (
+	TierExtremeCold Tier = iota
+	TierVeryCold
+	TierCold
+	TierCool
+	TierMild
+	TierWarm
+	TierHot
+)
+
+var tierBounds = []struct {
+	below float64
+	tier  Tier
+}{
+	{below: -20, tier: TierExtremeCold},
+	{below: -10, tier: TierVeryCold},
+	{below: 0, tier: TierCold},
+	{below: 10, tier: TierCool},
+	{below: 18, tier: TierMild},
+	{below: 26, tier: TierWarm},
+}
+
+var tierAdvice = map[Tier]string{
+	TierExtremeCold: "Stay inside if you can: insulated coat, thermal layers, face cover",
+	TierVeryCold:    "Winter coat, hat, scarf and gloves",
+	TierCold:        "Warm coat and a hat",
+	TierCool:        "Jacket or a warm sweater",
+	TierMild:        "Light jacket or long sleeves",
+	TierWarm:        "T-shirt weather",
+	TierHot:         "Light, breathable clothes and plenty of water",
+}
+
+// Clothing maps the feels-like temperature in °C to a clothing tier.
+func Clothing(feelsLike float64) Tier {
+	for _, b := range tierBounds {
+		if feelsLike < b.below {
+			return b.tier
+		}
+	}
+	return TierHot
+}
+
+func (t Tier) String() string {
+	return tierAdvice[t]
+}
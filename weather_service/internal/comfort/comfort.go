@@ -0,0 +1,112 @@
+package comfort
+
+import "math"
+
+// Thresholds outside of which the heat index and wind chill formulas are not defined.
+const (
+	HeatIndexMinTemp  = 26.7
+	WindChillMaxTemp  = 10
+	WindChillMinSpeed = 4.8
+)
+
+type Indices struct {
+	FeelsLike float64
+	DewPoint  float64
+	HeatIndex float64
+	WindChill float64
+	Humidex   float64
+	Clothing  Tier
+}
+
+// Compute derives every index from the air temperature in °C, relative humidity
+// in percent and wind speed in m/s.
+func Compute(temp, humidity, windSpeed float64) Indices {
+	wind := windSpeed * 3.6
+	dew := DewPoint(temp, humidity)
+	feels := FeelsLike(temp, humidity, wind)
+
+	return Indices{
+		FeelsLike: feels,
+		DewPoint:  dew,
+		HeatIndex: HeatIndex(temp, humidity),
+		WindChill: WindChill(temp, wind),
+		Humidex:   Humidex(temp, dew),
+		Clothing:  Clothing(feels),
+	}
+}
+
+// DewPoint uses the Magnus formula with the Sonntag constants.
+func DewPoint(temp, humidity float64) float64 {
+	const a, b = 17.62, 243.12
+
+	if humidity <= 0 {
+		humidity = 1
+	}
+	gamma := math.Log(humidity/100) + a*temp/(b+temp)
+	return b * gamma / (a - gamma)
+}
+
+// HeatIndex follows the NWS algorithm: the Steadman approximation for mild
+// conditions and the Rothfusz regression with its adjustments above 80 °F.
+// Below HeatIndexMinTemp the air temperature is returned.
+func HeatIndex(temp, humidity float64) float64 {
+	if temp < HeatIndexMinTemp {
+		return temp
+	}
+
+	t := celsiusToFahrenheit(temp)
+	rh := humidity
+
+	hi := 0.5 * (t + 61 + (t-68)*1.2 + rh*0.094)
+	if (hi+t)/2 < 80 {
+		return fahrenheitToCelsius(hi)
+	}
+
+	hi = -42.379 + 2.04901523*t + 10.14333127*rh - 0.22475541*t*rh - 0.00683783*t*t -
+		0.05481717*rh*rh + 0.00122874*t*t*rh + 0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+
+	switch {
+	case rh < 13 && t >= 80 && t <= 112:
+		hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+	case rh > 85 && t >= 80 && t <= 87:
+		hi += (rh - 85) / 10 * (87 - t) / 5
+	}
+	return fahrenheitToCelsius(hi)
+}
+
+// WindChill uses the joint North American formula with the wind in km/h.
+// Outside of its range the air temperature is returned.
+func WindChill(temp, wind float64) float64 {
+	if temp > WindChillMaxTemp || wind <= WindChillMinSpeed {
+		return temp
+	}
+	v := math.Pow(wind, 0.16)
+	return 13.12 + 0.6215*temp - 11.37*v + 0.3965*temp*v
+}
+
+// Humidex is the Canadian index computed from the temperature and dew point.
+func Humidex(temp, dewPoint float64) float64 {
+	e := 6.11 * math.Exp(5417.7530*(1/273.16-1/(273.15+dewPoint)))
+	return temp + 0.5555*(e-10)
+}
+
+// FeelsLike picks wind chill in the cold, heat index in the heat and the air
+// temperature in between. Wind is in km/h.
+func FeelsLike(temp, humidity, wind float64) float64 {
+	switch {
+	case temp <= WindChillMaxTemp && wind > WindChillMinSpeed:
+		return WindChill(temp, wind)
+	case temp >= HeatIndexMinTemp:
+		return HeatIndex(temp, humidity)
+	default:
+		return temp
+	}
+}
+
+func celsiusToFahrenheit(t float64) float64 {
+	return t*9/5 + 32
+}
+
+func fahrenheitToCelsius(t float64) float64 {
+	return (t - 32) * 5 / 9
+}
@@ -0,0 +1,143 @@
+package comfort
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const delta = 0.5
+
+func TestDewPoint(t *testing.T) {
+	var useCase = []struct {
+		Name     string
+		Temp     float64
+		Humidity float64
+		Expected float64
+	}{
+		{Name: "Saturated air", Temp: 15, Humidity: 100, Expected: 15},
+		{Name: "Room conditions", Temp: 20, Humidity: 50, Expected: 9.3},
+		{Name: "Hot and humid", Temp: 30, Humidity: 80, Expected: 26.2},
+		{Name: "Freezing and dry", Temp: -5, Humidity: 40, Expected: -16.6},
+		{Name: "Zero humidity is clamped", Temp: 20, Humidity: 0, Expected: -38},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			assert.InDelta(t, us.Expected, DewPoint(us.Temp, us.Humidity), delta)
+		})
+	}
+}
+
+func TestHeatIndex(t *testing.T) {
+	var useCase = []struct {
+		Name     string
+		Temp     float64
+		Humidity float64
+		Expected float64
+	}{
+		{Name: "Below range returns temperature", Temp: 20, Humidity: 90, Expected: 20},
+		{Name: "Mild, Steadman approximation", Temp: 27, Humidity: 40, Expected: 27.1},
+		{Name: "NWS table 90F 70%", Temp: 32.2, Humidity: 70, Expected: 41.1},
+		{Name: "NWS table 100F 50%", Temp: 37.8, Humidity: 50, Expected: 48.3},
+		{Name: "Dry adjustment", Temp: 40, Humidity: 10, Expected: 36.7},
+		{Name: "Humid adjustment", Temp: 28, Humidity: 95, Expected: 34.8},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			assert.InDelta(t, us.Expected, HeatIndex(us.Temp, us.Humidity), delta)
+		})
+	}
+}
+
+func TestWindChill(t *testing.T) {
+	var useCase = []struct {
+		Name     string
+		Temp     float64
+		Wind     float64
+		Expected float64
+	}{
+		{Name: "Too warm returns temperature", Temp: 15, Wind: 30, Expected: 15},
+		{Name: "Calm returns temperature", Temp: -10, Wind: 3, Expected: -10},
+		{Name: "Table -10C 20kmh", Temp: -10, Wind: 20, Expected: -17.9},
+		{Name: "Table -20C 30kmh", Temp: -20, Wind: 30, Expected: -32.6},
+		{Name: "Table 0C 10kmh", Temp: 0, Wind: 10, Expected: -3.3},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			assert.InDelta(t, us.Expected, WindChill(us.Temp, us.Wind), delta)
+		})
+	}
+}
+
+func TestHumidex(t *testing.T) {
+	var useCase = []struct {
+		Name     string
+		Temp     float64
+		DewPoint float64
+		Expected float64
+	}{
+		{Name: "Table 30C dew 15C", Temp: 30, DewPoint: 15, Expected: 34},
+		{Name: "Table 35C dew 25C", Temp: 35, DewPoint: 25, Expected: 47},
+		{Name: "Cold dry air", Temp: 5, DewPoint: -10, Expected: 1},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			assert.InDelta(t, us.Expected, Humidex(us.Temp, us.DewPoint), delta)
+		})
+	}
+}
+
+func TestFeelsLike(t *testing.T) {
+	var useCase = []struct {
+		Name     string
+		Temp     float64
+		Humidity float64
+		Wind     float64
+		Expected float64
+	}{
+		{Name: "Cold and windy uses wind chill", Temp: -10, Humidity: 70, Wind: 20, Expected: -17.9},
+		{Name: "Hot uses heat index", Temp: 32.2, Humidity: 70, Wind: 20, Expected: 41.1},
+		{Name: "Mild uses air temperature", Temp: 15, Humidity: 70, Wind: 20, Expected: 15},
+		{Name: "Cold and calm uses air temperature", Temp: 2, Humidity: 70, Wind: 2, Expected: 2},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			assert.InDelta(t, us.Expected, FeelsLike(us.Temp, us.Humidity, us.Wind), delta)
+		})
+	}
+}
+
+func TestClothing(t *testing.T) {
+	var useCase = []struct {
+		FeelsLike float64
+		Expected  Tier
+	}{
+		{FeelsLike: -30, Expected: TierExtremeCold},
+		{FeelsLike: -20, Expected: TierVeryCold},
+		{FeelsLike: -5, Expected: TierCold},
+		{FeelsLike: 0, Expected: TierCool},
+		{FeelsLike: 12, Expected: TierMild},
+		{FeelsLike: 22, Expected: TierWarm},
+		{FeelsLike: 26, Expected: TierHot},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Expected.String(), func(t *testing.T) {
+			assert.Equal(t, us.Expected, Clothing(us.FeelsLike))
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+	indices := Compute(-10, 70, 20/3.6)
+
+	assert.InDelta(t, -17.9, indices.FeelsLike, delta)
+	assert.InDelta(t, -10, indices.HeatIndex, delta)
+	assert.InDelta(t, -17.9, indices.WindChill, delta)
+	assert.Equal(t, TierVeryCold, indices.Clothing)
+	assert.Less(t, indices.DewPoint, -10.0)
+}
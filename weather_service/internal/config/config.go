@@ -1,13 +1,131 @@
 package config
 
-import "github.com/kelseyhightower/envconfig"
+import (
+	"errors"
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+const (
+	AuthQuery  = "query"
+	AuthHeader = "header"
+	AuthNone   = "none"
+)
+
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// optional placeholders may appear in any endpoint's params. They expand to
+// an empty string when the request does not set them.
+var optional = map[string]bool{"lang": true}
 
 type Config struct {
-	APIKey string `envconfig:"api_key"`
-	URL    string `envconfig:"url"`
-	Port   string `envconfig:"port"`
+	APIKey     string   `envconfig:"api_key"`
+	Current    Endpoint `envconfig:"current"`
+	OneCall    Endpoint `envconfig:"onecall"`
+	Geocode    Endpoint `envconfig:"geocode"`
+	Port       string   `envconfig:"port"`
+	ClimateDir string   `envconfig:"climate_dir" default:"data/climate"`
+}
+
+// Endpoint describes one upstream provider call. Params values may reference
+// request values as {name} placeholders, e.g. WEATHER_CURRENT_PARAMS=q:{city}.
+type Endpoint struct {
+	URL      string            `envconfig:"url"`
+	Params   map[string]string `envconfig:"params"`
+	AuthMode string            `envconfig:"auth_mode" default:"query"`
+	AuthName string            `envconfig:"auth_name" default:"appid"`
+	Timeout  time.Duration     `envconfig:"timeout" default:"10s"`
 }
 
 func (c *Config) Process() error {
-	return envconfig.Process("weather", c)
+	err := envconfig.Process("weather", c)
+	if err != nil {
+		return err
+	}
+
+	err = c.Current.Validate(c.APIKey, "city")
+	if err != nil {
+		return fmt.Errorf("invalid current weather endpoint: %w", err)
+	}
+
+	err = c.OneCall.Validate(c.APIKey, "lat", "lon")
+	if err != nil {
+		return fmt.Errorf("invalid onecall endpoint: %w", err)
+	}
+
+	err = c.Geocode.Validate(c.APIKey, "city")
+	if err != nil {
+		return fmt.Errorf("invalid geocode endpoint: %w", err)
+	}
+	return nil
+}
+
+// Validate checks the endpoint is usable with the API key and that its params
+// use every one of vars and nothing else but the optional placeholders.
+// Temperatures are read as kelvin, so a units param may only be standard.
+func (e Endpoint) Validate(apiKey string, vars ...string) error {
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		return fmt.Errorf("failed to parse url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("url %q must be an absolute http(s) url", e.URL)
+	}
+
+	switch e.AuthMode {
+	case AuthQuery, AuthHeader:
+		if e.AuthName == "" {
+			return errors.New("auth name is required")
+		}
+		if apiKey == "" {
+			return errors.New("api key is required")
+		}
+	case AuthNone:
+	default:
+		return fmt.Errorf("unknown auth mode %q", e.AuthMode)
+	}
+
+	if e.Timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+
+	// The service converts temperatures from kelvin, so the provider must
+	// answer in its standard units.
+	if units, ok := e.Params["units"]; ok && units != "standard" {
+		return fmt.Errorf("units must be standard, got %q", units)
+	}
+
+	used := map[string]bool{}
+	for _, value := range e.Params {
+		for _, m := range placeholder.FindAllStringSubmatch(value, -1) {
+			used[m[1]] = true
+		}
+	}
+
+	for _, v := range vars {
+		if !used[v] {
+			return fmt.Errorf("params must use {%s}", v)
+		}
+		delete(used, v)
+	}
+	for v := range used {
+		if !optional[v] {
+			return fmt.Errorf("unknown placeholder {%s}", v)
+		}
+	}
+	return nil
+}
+
+// Expand substitutes vars into the endpoint params.
+func (e Endpoint) Expand(vars map[string]string) url.Values {
+	values := url.Values{}
+	for name, value := range e.Params {
+		values.Set(name, placeholder.ReplaceAllStringFunc(value, func(m string) string {
+			return vars[m[1:len(m)-1]]
+		}))
+	}
+	return values
 }
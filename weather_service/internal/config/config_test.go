@@ -0,0 +1,95 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"os"
+	"testing"
+	"time"
+)
+
+func validEndpoint() Endpoint {
+	return Endpoint{
+		URL:      "https://api.openweathermap.org/data/2.5/weather",
+		Params:   map[string]string{"q": "{city}"},
+		AuthMode: AuthQuery,
+		AuthName: "appid",
+		Timeout:  time.Second,
+	}
+}
+
+func TestEndpoint_Validate(t *testing.T) {
+	var useCase = []struct {
+		Name    string
+		Modify  func(e *Endpoint)
+		APIKey  string
+		IsError bool
+	}{
+		{Name: "Valid endpoint", Modify: func(e *Endpoint) {}, APIKey: "key", IsError: false},
+		{Name: "Header auth", Modify: func(e *Endpoint) { e.AuthMode = AuthHeader; e.AuthName = "X-Api-Key" }, APIKey: "key", IsError: false},
+		{Name: "No auth without key", Modify: func(e *Endpoint) { e.AuthMode = AuthNone }, APIKey: "", IsError: false},
+		{Name: "Missing key", Modify: func(e *Endpoint) {}, APIKey: "", IsError: true},
+		{Name: "Relative url", Modify: func(e *Endpoint) { e.URL = "/data/2.5/weather" }, APIKey: "key", IsError: true},
+		{Name: "Old Sprintf format", Modify: func(e *Endpoint) { e.URL = "%s" }, APIKey: "key", IsError: true},
+		{Name: "Unknown auth mode", Modify: func(e *Endpoint) { e.AuthMode = "cookie" }, APIKey: "key", IsError: true},
+		{Name: "Empty auth name", Modify: func(e *Endpoint) { e.AuthName = "" }, APIKey: "key", IsError: true},
+		{Name: "Zero timeout", Modify: func(e *Endpoint) { e.Timeout = 0 }, APIKey: "key", IsError: true},
+		{Name: "Missing placeholder", Modify: func(e *Endpoint) { e.Params = map[string]string{"units": "standard"} }, APIKey: "key", IsError: true},
+		{Name: "Unknown placeholder", Modify: func(e *Endpoint) { e.Params["mode"] = "{mode}" }, APIKey: "key", IsError: true},
+		{Name: "Standard units", Modify: func(e *Endpoint) { e.Params["units"] = "standard" }, APIKey: "key", IsError: false},
+		{Name: "Metric units", Modify: func(e *Endpoint) { e.Params["units"] = "metric" }, APIKey: "key", IsError: true},
+		{Name: "Optional placeholder", Modify: func(e *Endpoint) { e.Params["lang"] = "{lang}" }, APIKey: "key", IsError: false},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			e := validEndpoint()
+			us.Modify(&e)
+			err := e.Validate(us.APIKey, "city")
+			if us.IsError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEndpoint_Expand(t *testing.T) {
+	e := Endpoint{Params: map[string]string{"lat": "{lat}", "lon": "{lon}", "mode": "json", "q": "{city},{country}"}}
+
+	values := e.Expand(map[string]string{"lat": "53.9", "lon": "27.56", "city": "Minsk", "country": "BY"})
+
+	assert.Equal(t, "lat=53.9&lon=27.56&mode=json&q=Minsk%2CBY", values.Encode())
+}
+
+func TestConfig_Process(t *testing.T) {
+	env := map[string]string{
+		"WEATHER_API_KEY":         "key",
+		"WEATHER_CURRENT_URL":     "https://api.openweathermap.org/data/2.5/weather",
+		"WEATHER_CURRENT_PARAMS":  "q:{city}",
+		"WEATHER_ONECALL_URL":     "https://api.openweathermap.org/data/3.0/onecall",
+		"WEATHER_ONECALL_PARAMS":  "lat:{lat},lon:{lon},units:standard",
+		"WEATHER_ONECALL_TIMEOUT": "3s",
+		"WEATHER_GEOCODE_URL":     "https://api.openweathermap.org/geo/1.0/direct",
+		"WEATHER_GEOCODE_PARAMS":  "q:{city},limit:5",
+	}
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+
+	var cfg Config
+	assert.NoError(t, cfg.Process())
+	assert.Equal(t, AuthQuery, cfg.Current.AuthMode)
+	assert.Equal(t, 10*time.Second, cfg.Current.Timeout)
+	assert.Equal(t, 3*time.Second, cfg.OneCall.Timeout)
+	assert.Equal(t, "standard", cfg.OneCall.Params["units"])
+	assert.Equal(t, "5", cfg.Geocode.Params["limit"])
+
+	t.Setenv("WEATHER_ONECALL_PARAMS", "lat:{lat},lon:{lon},units:metric")
+	var metric Config
+	assert.Error(t, metric.Process())
+
+	os.Unsetenv("WEATHER_ONECALL_PARAMS")
+	var missing Config
+	assert.Error(t, missing.Process())
+}
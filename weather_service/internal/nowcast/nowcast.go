@@ -0,0 +1,158 @@
+package nowcast
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// WetIntensity is the precipitation rate in mm/h from which a minute counts as wet.
+	WetIntensity = 0.1
+	// WetProbability is the hourly probability from which an hour counts as wet.
+	WetProbability = 0.5
+
+	lightIntensity = 2.5
+	heavyIntensity = 7.6
+)
+
+type Point struct {
+	Time        time.Time
+	Probability float64
+	Intensity   float64
+}
+
+func (p Point) wet() bool {
+	return p.Intensity >= WetIntensity
+}
+
+func (p Point) wetHour() bool {
+	return p.Probability >= WetProbability || p.Intensity >= WetIntensity
+}
+
+// Summarize answers "will it rain" for the points following now. Minutely points
+// give the precise start and end, hourly points extend the answer past the last minute.
+func Summarize(now time.Time, minutely, hourly []Point) string {
+	minutely = after(now.Add(-time.Minute), minutely)
+	hourly = after(now.Add(-time.Hour), hourly)
+
+	start, end, peak, found := wetSpell(minutely)
+	if !found {
+		return summarizeHourly(now, hourly)
+	}
+
+	open := end.IsZero()
+	if open {
+		end, peak = extendSpell(minutely[len(minutely)-1].Time, hourly, peak)
+	}
+
+	kind := describe(peak)
+	if !start.After(now) {
+		if end.IsZero() {
+			return fmt.Sprintf("%s now, not stopping in the next %s", kind, duration(lastTime(minutely, hourly).Sub(now)))
+		}
+		return fmt.Sprintf("%s now, stopping in %s", kind, duration(end.Sub(now)))
+	}
+
+	if end.IsZero() {
+		return fmt.Sprintf("%s starting in %s, lasting for hours", kind, duration(start.Sub(now)))
+	}
+	return fmt.Sprintf("%s starting in %s, lasting %s", kind, duration(start.Sub(now)), duration(end.Sub(start)))
+}
+
+func summarizeHourly(now time.Time, hourly []Point) string {
+	for _, p := range hourly {
+		if !p.wetHour() {
+			continue
+		}
+		if !p.Time.After(now) {
+			return fmt.Sprintf("Rain likely within the hour (%d%%)", percent(p.Probability))
+		}
+		return fmt.Sprintf("Rain likely in %s (%d%%)", duration(p.Time.Sub(now)), percent(p.Probability))
+	}
+
+	if len(hourly) == 0 {
+		return "No rain expected in the next hour"
+	}
+	return fmt.Sprintf("No rain expected in the next %s", duration(hourly[len(hourly)-1].Time.Add(time.Hour).Sub(now)))
+}
+
+// wetSpell finds the first run of wet minutes. A zero end means the run lasts
+// until the end of the data.
+func wetSpell(points []Point) (start, end time.Time, peak float64, found bool) {
+	for _, p := range points {
+		switch {
+		case p.wet() && !found:
+			start, peak, found = p.Time, p.Intensity, true
+		case p.wet():
+			peak = math.Max(peak, p.Intensity)
+		case found:
+			return start, p.Time, peak, true
+		}
+	}
+	return start, time.Time{}, peak, found
+}
+
+// extendSpell continues a spell that outlasts the minutely data with hourly points.
+func extendSpell(from time.Time, hourly []Point, peak float64) (time.Time, float64) {
+	for _, p := range hourly {
+		if p.Time.Add(time.Hour).Before(from) {
+			continue
+		}
+		if !p.wetHour() {
+			if p.Time.Before(from) {
+				return from, peak
+			}
+			return p.Time, peak
+		}
+		peak = math.Max(peak, p.Intensity)
+	}
+	return time.Time{}, peak
+}
+
+func after(t time.Time, points []Point) []Point {
+	for i, p := range points {
+		if p.Time.After(t) {
+			return points[i:]
+		}
+	}
+	return nil
+}
+
+func lastTime(minutely, hourly []Point) time.Time {
+	if len(hourly) > 0 {
+		return hourly[len(hourly)-1].Time.Add(time.Hour)
+	}
+	return minutely[len(minutely)-1].Time.Add(time.Minute)
+}
+
+func describe(intensity float64) string {
+	switch {
+	case intensity < lightIntensity:
+		return "Light rain"
+	case intensity >= heavyIntensity:
+		return "Heavy rain"
+	default:
+		return "Rain"
+	}
+}
+
+func duration(d time.Duration) string {
+	if d < time.Hour {
+		minutes := int(math.Round(d.Minutes()/5) * 5)
+		if minutes < 5 {
+			minutes = 5
+		}
+		return fmt.Sprintf("~%d min", minutes)
+	}
+
+	hours := d.Hours()
+	if whole := math.Round(hours); math.Abs(hours-whole) < 0.25 {
+		return fmt.Sprintf("~%dh", int(whole))
+	}
+	return fmt.Sprintf("~%dh30m", int(hours))
+}
+
+func percent(p float64) int {
+	return int(math.Round(p * 100))
+}
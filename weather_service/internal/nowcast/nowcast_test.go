@@ -0,0 +1,87 @@
+package nowcast
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2023, time.May, 10, 14, 0, 0, 0, time.UTC)
+
+// minutes builds one point per minute from now, wet with the given intensity
+// for minutes in [from, to).
+func minutes(from, to int, intensity float64) []Point {
+	points := make([]Point, 0, 60)
+	for i := 0; i < 60; i++ {
+		p := Point{Time: now.Add(time.Duration(i) * time.Minute)}
+		if i >= from && i < to {
+			p.Intensity = intensity
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// hours builds hourly points from now with the given probabilities.
+func hours(probabilities ...float64) []Point {
+	points := make([]Point, 0, len(probabilities))
+	for i, p := range probabilities {
+		points = append(points, Point{Time: now.Add(time.Duration(i) * time.Hour), Probability: p})
+	}
+	return points
+}
+
+func TestSummarize(t *testing.T) {
+	var useCase = []struct {
+		Name     string
+		Minutely []Point
+		Hourly   []Point
+		Expected string
+	}{
+		{Name: "Dry everywhere", Minutely: minutes(0, 0, 0), Hourly: hours(0, 0.1, 0.2, 0.1), Expected: "No rain expected in the next ~4h"},
+		{Name: "No data", Expected: "No rain expected in the next hour"},
+		{Name: "Rain starting within the hour", Minutely: minutes(25, 60, 1), Hourly: hours(0.6, 0.1), Expected: "Light rain starting in ~25 min, lasting ~35 min"},
+		{Name: "Rain starting, lasting into hourly data", Minutely: minutes(25, 60, 1), Hourly: hours(0.6, 0.8, 0.1), Expected: "Light rain starting in ~25 min, lasting ~1h30m"},
+		{Name: "Short shower", Minutely: minutes(10, 30, 3), Hourly: hours(0.4, 0.1), Expected: "Rain starting in ~10 min, lasting ~20 min"},
+		{Name: "Heavy rain stopping", Minutely: minutes(0, 40, 9), Hourly: hours(0.9, 0.2), Expected: "Heavy rain now, stopping in ~40 min"},
+		{Name: "Rain now ends with minutely data", Minutely: minutes(0, 60, 1), Hourly: hours(0.9, 0.3), Expected: "Light rain now, stopping in ~1h"},
+		{Name: "Rain now lasting past the hourly data", Minutely: minutes(0, 60, 1), Hourly: hours(0.9, 0.9), Expected: "Light rain now, not stopping in the next ~2h"},
+		{Name: "Rain continues into hourly data", Minutely: minutes(0, 60, 1), Hourly: hours(0.9, 0.8, 0.7, 0.1), Expected: "Light rain now, stopping in ~3h"},
+		{Name: "Rain later from hourly data", Minutely: minutes(0, 0, 0), Hourly: hours(0.1, 0.2, 0.7), Expected: "Rain likely in ~2h (70%)"},
+		{Name: "Hourly only, wet now", Hourly: hours(0.55), Expected: "Rain likely within the hour (55%)"},
+		{Name: "Drizzle below threshold is dry", Minutely: minutes(0, 60, 0.05), Hourly: hours(0.2), Expected: "No rain expected in the next ~1h"},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			assert.Equal(t, us.Expected, Summarize(now, us.Minutely, us.Hourly))
+		})
+	}
+}
+
+func TestSummarize_SkipsPastPoints(t *testing.T) {
+	minutely := minutes(0, 10, 1)
+	later := now.Add(30 * time.Minute)
+
+	assert.Equal(t, "No rain expected in the next ~30 min", Summarize(later, minutely, hours(0.2)))
+}
+
+func TestDuration(t *testing.T) {
+	var useCase = []struct {
+		Input    time.Duration
+		Expected string
+	}{
+		{Input: time.Minute, Expected: "~5 min"},
+		{Input: 23 * time.Minute, Expected: "~25 min"},
+		{Input: 58 * time.Minute, Expected: "~60 min"},
+		{Input: time.Hour, Expected: "~1h"},
+		{Input: 90 * time.Minute, Expected: "~1h30m"},
+		{Input: 170 * time.Minute, Expected: "~3h"},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Expected, func(t *testing.T) {
+			assert.Equal(t, us.Expected, duration(us.Input))
+		})
+	}
+}
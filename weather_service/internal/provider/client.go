@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"weather_service/internal/config"
+)
+
+// Client calls a single configured provider endpoint.
+type Client struct {
+	endpoint config.Endpoint
+	apiKey   string
+	http     *http.Client
+}
+
+func NewClient(endpoint config.Endpoint, apiKey string) *Client {
+	return &Client{
+		endpoint: endpoint,
+		apiKey:   apiKey,
+		http:     &http.Client{Timeout: endpoint.Timeout},
+	}
+}
+
+// GetJSON requests the endpoint with vars substituted into its params and
+// decodes the JSON response into v.
+func (c *Client) GetJSON(ctx context.Context, vars map[string]string, v interface{}) error {
+	req, err := c.newRequest(ctx, vars)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) newRequest(ctx context.Context, vars map[string]string) (*http.Request, error) {
+	u, err := url.Parse(c.endpoint.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	query := u.Query()
+	for name, values := range c.endpoint.Expand(vars) {
+		query[name] = values
+	}
+	if c.endpoint.AuthMode == config.AuthQuery {
+		query.Set(c.endpoint.AuthName, c.apiKey)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.endpoint.AuthMode == config.AuthHeader {
+		req.Header.Set(c.endpoint.AuthName, c.apiKey)
+	}
+	return req, nil
+}
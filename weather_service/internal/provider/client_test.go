@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"weather_service/internal/config"
+)
+
+type body struct {
+	Name string `json:"name"`
+}
+
+func TestClient_GetJSON(t *testing.T) {
+	var useCase = []struct {
+		Name     string
+		AuthMode string
+		Status   int
+		Query    string
+		Header   string
+		IsError  bool
+	}{
+		{Name: "Key in query", AuthMode: config.AuthQuery, Status: http.StatusOK, Query: "appid=secret&q=New+York&units=metric", IsError: false},
+		{Name: "Key in header", AuthMode: config.AuthHeader, Status: http.StatusOK, Query: "q=New+York&units=metric", Header: "secret", IsError: false},
+		{Name: "No auth", AuthMode: config.AuthNone, Status: http.StatusOK, Query: "q=New+York&units=metric", IsError: false},
+		{Name: "Failed on provider error", AuthMode: config.AuthQuery, Status: http.StatusNotFound, Query: "appid=secret&q=New+York&units=metric", IsError: true},
+	}
+
+	for _, us := range useCase {
+		t.Run(us.Name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/data/weather", r.URL.Path)
+				assert.Equal(t, us.Query, r.URL.RawQuery)
+				assert.Equal(t, us.Header, r.Header.Get("appid"))
+				w.WriteHeader(us.Status)
+				_, _ = w.Write([]byte(`{"name":"New York"}`))
+			}))
+			defer srv.Close()
+
+			c := NewClient(config.Endpoint{
+				URL:      srv.URL + "/data/weather?units=metric",
+				Params:   map[string]string{"q": "{city}"},
+				AuthMode: us.AuthMode,
+				AuthName: "appid",
+				Timeout:  time.Second,
+			}, "secret")
+
+			var data body
+			err := c.GetJSON(context.Background(), map[string]string{"city": "New York"}, &data)
+			if us.IsError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "New York", data.Name)
+			}
+		})
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	c := NewClient(config.Endpoint{URL: srv.URL, AuthMode: config.AuthNone, Timeout: 10 * time.Millisecond}, "")
+
+	var data body
+	assert.Error(t, c.GetJSON(context.Background(), nil, &data))
+}
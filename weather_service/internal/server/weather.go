@@ -1,10 +1,14 @@
 package server
 
 import (
+	"context"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"log"
 	"net"
+	"time"
 	"weather_service/api/pb"
 	"weather_service/internal/config"
 	"weather_service/internal/service"
@@ -15,6 +19,7 @@ type WeatherServer struct {
 	cfg     *config.Config
 	client  *grpc.Server
 	service *service.GRPCServer
+	health  *health.Server
 }
 
 func NewWeatherServer(logger *logrus.Logger, cfg *config.Config, service *service.GRPCServer) *WeatherServer {
@@ -23,12 +28,40 @@ func NewWeatherServer(logger *logrus.Logger, cfg *config.Config, service *servic
 		cfg:     cfg,
 		client:  grpc.NewServer(),
 		service: service,
+		health:  health.NewServer(),
 	}
 }
 
 func (w *WeatherServer) Register() {
 
 	w.client.RegisterService(&pb.GetWeather_ServiceDesc, w.service)
+	grpc_health_v1.RegisterHealthServer(w.client, w.health)
+}
+
+// WatchHealth reports the service as not serving while the provider does not
+// answer. It probes the provider every interval until ctx is done.
+func (w *WeatherServer) WatchHealth(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		probeCtx, cancel := context.WithTimeout(ctx, interval)
+		err := w.service.Ping(probeCtx)
+		cancel()
+
+		serving := grpc_health_v1.HealthCheckResponse_SERVING
+		if err != nil {
+			w.logger.Warningf("provider health check failed: %s", err)
+			serving = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		}
+		w.health.SetServingStatus("", serving)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 func (w *WeatherServer) Start() {
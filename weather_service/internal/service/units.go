@@ -0,0 +1,49 @@
+package service
+
+import "fmt"
+
+const (
+	UnitsMetric   = "metric"
+	UnitsImperial = "imperial"
+)
+
+// units converts metric values for the text responses.
+type units string
+
+func parseUnits(s string) (units, error) {
+	switch s {
+	case "", UnitsMetric:
+		return UnitsMetric, nil
+	case UnitsImperial:
+		return UnitsImperial, nil
+	}
+	return "", fmt.Errorf("unknown units %q", s)
+}
+
+func (u units) temp(celsius float64) float64 {
+	if u == UnitsImperial {
+		return celsius*9/5 + 32
+	}
+	return celsius
+}
+
+func (u units) speed(ms float64) float64 {
+	if u == UnitsImperial {
+		return ms * 2.236936
+	}
+	return ms
+}
+
+func (u units) tempUnit() string {
+	if u == UnitsImperial {
+		return "°F"
+	}
+	return "°C"
+}
+
+func (u units) speedUnit() string {
+	if u == UnitsImperial {
+		return "mph"
+	}
+	return "m/s"
+}
@@ -2,59 +2,469 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
-	"net/http"
+	"strconv"
+	"strings"
+	"time"
 	"weather_service/api/pb"
+	"weather_service/internal/chart"
+	"weather_service/internal/climate"
+	"weather_service/internal/comfort"
 	"weather_service/internal/config"
+	"weather_service/internal/nowcast"
+	"weather_service/internal/provider"
+)
+
+const (
+	defaultNowcastHours = 6
+	maxNowcastHours     = 48
+	defaultChartHours   = 48
+	defaultForecastDays = 3
+	maxForecastDays     = 8
+	// probeCity is asked for in health checks.
+	probeCity = "London"
 )
 
 type GRPCServer struct {
-	cfg    *config.Config
-	logger *logrus.Logger
+	cfg     *config.Config
+	logger  *logrus.Logger
+	climate *climate.Dataset
+	current *provider.Client
+	oneCall *provider.Client
+	geocode *provider.Client
 }
 
-func NewGRPCServer(cfg *config.Config, logger *logrus.Logger) *GRPCServer {
+func NewGRPCServer(cfg *config.Config, logger *logrus.Logger, climate *climate.Dataset) *GRPCServer {
 	return &GRPCServer{
-		cfg:    cfg,
-		logger: logger,
+		cfg:     cfg,
+		logger:  logger,
+		climate: climate,
+		current: provider.NewClient(cfg.Current, cfg.APIKey),
+		oneCall: provider.NewClient(cfg.OneCall, cfg.APIKey),
+		geocode: provider.NewClient(cfg.Geocode, cfg.APIKey),
 	}
 }
 
 func (g *GRPCServer) Get(ctx context.Context, req *pb.Request) (*pb.Response, error) {
+	u, err := parseUnits(req.GetUnits())
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetCoord() != nil {
+		return g.GetWeatherAt(ctx, req.GetCity(), req.GetCoord(), u, req.GetLang()), nil
+	}
+	return g.GetWeather(ctx, req.GetCity(), u, req.GetLang()), nil
+}
+
+func (g *GRPCServer) Geocode(ctx context.Context, req *pb.GeocodeRequest) (*pb.GeocodeResponse, error) {
+	var data []struct {
+		Name    string  `json:"name"`
+		State   string  `json:"state"`
+		Country string  `json:"country"`
+		Lat     float64 `json:"lat"`
+		Lon     float64 `json:"lon"`
+	}
+	err := g.geocode.GetJSON(ctx, map[string]string{"city": req.GetCity()}, &data)
+	if err != nil {
+		g.logger.Printf("request to geocode failed: %s\n", err.Error())
+		return nil, err
+	}
+
+	places := make([]*pb.Place, 0, len(data))
+	for _, p := range data {
+		places = append(places, &pb.Place{
+			Name:    p.Name,
+			State:   p.State,
+			Country: p.Country,
+			Coord:   &pb.Coordinates{Lat: p.Lat, Lon: p.Lon},
+		})
+	}
+	return &pb.GeocodeResponse{Places: places}, nil
+}
+
+func (g *GRPCServer) Nowcast(ctx context.Context, req *pb.NowcastRequest) (*pb.NowcastResponse, error) {
+	hours := int(req.GetHours())
+	if hours <= 0 {
+		hours = defaultNowcastHours
+	}
+	if hours > maxNowcastHours {
+		hours = maxNowcastHours
+	}
+
+	current, err := g.fetchCurrent(ctx, req.GetCity(), "")
+	if err != nil {
+		g.logger.Printf("failed to locate city %q: %s\n", req.GetCity(), err.Error())
+		return nil, err
+	}
+
+	forecast, err := g.fetchOneCall(ctx, current.Coord.Lat, current.Coord.Lon, "")
+	if err != nil {
+		g.logger.Printf("request to onecall failed: %s\n", err.Error())
+		return nil, err
+	}
+
+	minutely := forecast.minutely()
+	hourly := forecast.hourly()
+	if len(hourly) > hours {
+		hourly = hourly[:hours]
+	}
+
+	return &pb.NowcastResponse{
+		City:     current.Name,
+		Summary:  nowcast.Summarize(time.Now(), minutely, hourly),
+		Minutely: toPrecipitation(minutely),
+		Hourly:   toPrecipitation(hourly),
+	}, nil
+}
+
+func (g *GRPCServer) Compare(ctx context.Context, req *pb.ClimateRequest) (*pb.ClimateResponse, error) {
+	current, err := g.fetchCurrent(ctx, req.GetCity(), "")
+	if err != nil {
+		g.logger.Printf("failed to locate city %q: %s\n", req.GetCity(), err.Error())
+		return nil, err
+	}
+
+	temp := kelvinToCelsius(current.Main.Temp)
+	date := time.Now().In(time.FixedZone(current.Name, current.Timezone))
+
+	c, err := g.climate.Compare(current.Name, current.Coord.Lat, current.Coord.Lon, date, temp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s with climate: %w", current.Name, err)
+	}
+
+	return &pb.ClimateResponse{
+		City:       current.Name,
+		Station:    c.Station.City,
+		Date:       date.Format("2006-01-02"),
+		Temp:       temp,
+		Normal:     c.Normal.Mean,
+		NormalHigh: c.Normal.MeanHigh,
+		NormalLow:  c.Normal.MeanLow,
+		RecordHigh: c.Normal.RecordHigh,
+		RecordLow:  c.Normal.RecordLow,
+		Anomaly:    c.Anomaly,
+		Summary:    c.Summary(),
+	}, nil
+}
+
+func (g *GRPCServer) Chart(ctx context.Context, req *pb.ChartRequest) (*pb.ChartResponse, error) {
+	hours := int(req.GetHours())
+	if hours <= 0 || hours > defaultChartHours {
+		hours = defaultChartHours
+	}
+
+	current, err := g.fetchCurrent(ctx, req.GetCity(), "")
+	if err != nil {
+		g.logger.Printf("failed to locate city %q: %s\n", req.GetCity(), err.Error())
+		return nil, err
+	}
+
+	forecast, err := g.fetchOneCall(ctx, current.Coord.Lat, current.Coord.Lon, "")
+	if err != nil {
+		g.logger.Printf("request to onecall failed: %s\n", err.Error())
+		return nil, err
+	}
+
+	points := forecast.chart()
+	if len(points) > hours {
+		points = points[:hours]
+	}
+
+	img, err := chart.Render(fmt.Sprintf("%s, next %d hours", current.Name, len(points)), points)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render chart: %w", err)
+	}
+
+	return &pb.ChartResponse{
+		City:        current.Name,
+		Image:       img,
+		ContentType: "image/png",
+	}, nil
+}
+
+func (g *GRPCServer) Forecast(ctx context.Context, req *pb.ForecastRequest) (*pb.ForecastResponse, error) {
+	days := int(req.GetDays())
+	if days <= 0 {
+		days = defaultForecastDays
+	}
+	if days > maxForecastDays {
+		days = maxForecastDays
+	}
+
+	u, err := parseUnits(req.GetUnits())
+	if err != nil {
+		return nil, err
+	}
 
-	temps := g.GetWeather(req.GetCity())
-	return &pb.Response{Response: temps}, nil
+	name, lat, lon := placeName(req.GetCity(), req.GetCoord()), req.GetCoord().GetLat(), req.GetCoord().GetLon()
+	if req.GetCoord() == nil {
+		current, err := g.fetchCurrent(ctx, req.GetCity(), req.GetLang())
+		if err != nil {
+			g.logger.Printf("failed to locate city %q: %s\n", req.GetCity(), err.Error())
+			return nil, err
+		}
+		name, lat, lon = current.Name, current.Coord.Lat, current.Coord.Lon
+	}
+
+	forecast, err := g.fetchOneCall(ctx, lat, lon, req.GetLang())
+	if err != nil {
+		g.logger.Printf("request to onecall failed: %s\n", err.Error())
+		return nil, err
+	}
+
+	daily := forecast.daily()
+	if len(daily) > days {
+		daily = daily[:days]
+	}
+
+	lines := make([]string, 0, len(daily)+1)
+	lines = append(lines, fmt.Sprintf("City: %s", name))
+	for _, d := range daily {
+		lines = append(lines, fmt.Sprintf("%s: %.0f..%.0f %s, %s, %.0f%% precipitation, wind %.0f %s",
+			d.Date, u.temp(d.TempMin), u.temp(d.TempMax), u.tempUnit(), d.Description, d.Probability*100,
+			u.speed(d.WindSpeed), u.speedUnit()))
+	}
+
+	return &pb.ForecastResponse{
+		City:     name,
+		Days:     daily,
+		Response: strings.Join(lines, "\n"),
+		Timezone: forecast.Timezone,
+	}, nil
 }
 
 type respBody struct {
+	Name     string `json:"name"`
+	Timezone int    `json:"timezone"`
+	Coord    struct {
+		Lat float64 `json:"lat"`
+		Lon float64 `json:"lon"`
+	} `json:"coord"`
 	Main struct {
-		Temp float64 `json:"temp"`
+		Temp     float64 `json:"temp"`
+		Humidity float64 `json:"humidity"`
 	} `json:"main"`
+	Wind struct {
+		Speed float64 `json:"speed"`
+	} `json:"wind"`
 }
 
-func (g *GRPCServer) GetWeather(city string) string {
+type oneCallBody struct {
+	Timezone       string `json:"timezone"`
+	TimezoneOffset int    `json:"timezone_offset"`
+	Current        struct {
+		Temp      float64 `json:"temp"`
+		Humidity  float64 `json:"humidity"`
+		WindSpeed float64 `json:"wind_speed"`
+	} `json:"current"`
+	Minutely []struct {
+		Dt            int64   `json:"dt"`
+		Precipitation float64 `json:"precipitation"`
+	} `json:"minutely"`
+	Hourly []struct {
+		Dt        int64   `json:"dt"`
+		Temp      float64 `json:"temp"`
+		WindSpeed float64 `json:"wind_speed"`
+		WindDeg   float64 `json:"wind_deg"`
+		Pop       float64 `json:"pop"`
+		Rain      struct {
+			OneHour float64 `json:"1h"`
+		} `json:"rain"`
+		Snow struct {
+			OneHour float64 `json:"1h"`
+		} `json:"snow"`
+	} `json:"hourly"`
+	Daily []struct {
+		Dt   int64 `json:"dt"`
+		Temp struct {
+			Min float64 `json:"min"`
+			Max float64 `json:"max"`
+		} `json:"temp"`
+		Pop       float64 `json:"pop"`
+		WindSpeed float64 `json:"wind_speed"`
+		Weather   []struct {
+			Description string `json:"description"`
+		} `json:"weather"`
+	} `json:"daily"`
+}
 
-	resp, err := http.Get(fmt.Sprintf(g.cfg.URL, g.cfg.APIKey, city))
+func (o oneCallBody) minutely() []nowcast.Point {
+	points := make([]nowcast.Point, 0, len(o.Minutely))
+	for _, m := range o.Minutely {
+		var probability float64
+		if m.Precipitation >= nowcast.WetIntensity {
+			probability = 1
+		}
+		points = append(points, nowcast.Point{
+			Time:        time.Unix(m.Dt, 0),
+			Probability: probability,
+			Intensity:   m.Precipitation,
+		})
+	}
+	return points
+}
+
+func (o oneCallBody) hourly() []nowcast.Point {
+	points := make([]nowcast.Point, 0, len(o.Hourly))
+	for _, h := range o.Hourly {
+		points = append(points, nowcast.Point{
+			Time:        time.Unix(h.Dt, 0),
+			Probability: h.Pop,
+			Intensity:   h.Rain.OneHour + h.Snow.OneHour,
+		})
+	}
+	return points
+}
+
+func (o oneCallBody) location() *time.Location {
+	loc, err := time.LoadLocation(o.Timezone)
 	if err != nil {
-		g.logger.Printf("request to openweathermap failed: %s\n", err.Error())
-		return "incorrect name of city"
+		return time.FixedZone(o.Timezone, o.TimezoneOffset)
 	}
+	return loc
+}
 
-	var data respBody
-	err = json.NewDecoder(resp.Body).Decode(&data)
+func (o oneCallBody) daily() []*pb.DailyForecast {
+	loc := o.location()
+
+	days := make([]*pb.DailyForecast, 0, len(o.Daily))
+	for _, d := range o.Daily {
+		var description string
+		if len(d.Weather) > 0 {
+			description = d.Weather[0].Description
+		}
+		days = append(days, &pb.DailyForecast{
+			Date:        time.Unix(d.Dt, 0).In(loc).Format("Mon 02 Jan"),
+			TempMin:     kelvinToCelsius(d.Temp.Min),
+			TempMax:     kelvinToCelsius(d.Temp.Max),
+			Probability: d.Pop,
+			WindSpeed:   d.WindSpeed,
+			Description: description,
+		})
+	}
+	return days
+}
+
+func (o oneCallBody) chart() []chart.Point {
+	loc := o.location()
+
+	points := make([]chart.Point, 0, len(o.Hourly))
+	for _, h := range o.Hourly {
+		points = append(points, chart.Point{
+			Time:          time.Unix(h.Dt, 0).In(loc),
+			Temp:          kelvinToCelsius(h.Temp),
+			Precipitation: h.Rain.OneHour + h.Snow.OneHour,
+			WindSpeed:     h.WindSpeed,
+			WindDeg:       h.WindDeg,
+		})
+	}
+	return points
+}
+
+func toPrecipitation(points []nowcast.Point) []*pb.Precipitation {
+	res := make([]*pb.Precipitation, 0, len(points))
+	for _, p := range points {
+		res = append(res, &pb.Precipitation{
+			Time:        p.Time.Unix(),
+			Probability: p.Probability,
+			Intensity:   p.Intensity,
+		})
+	}
+	return res
+}
+
+func (g *GRPCServer) GetWeather(ctx context.Context, city string, u units, lang string) *pb.Response {
+
+	data, err := g.fetchCurrent(ctx, city, lang)
 	if err != nil {
-		g.logger.Printf("failed to decode response body: %s\n", err.Error())
-		return "incorrect name of city"
+		g.logger.Printf("request to openweathermap failed: %s\n", err.Error())
+		return &pb.Response{Response: "incorrect name of city"}
 	}
 
 	if data.Main.Temp == 0 {
-		return ""
+		return &pb.Response{}
+	}
+
+	return weatherResponse(city, data.Name, kelvinToCelsius(data.Main.Temp), data.Main.Humidity, data.Wind.Speed, u)
+}
+
+// GetWeatherAt returns the current weather at coord, named city.
+func (g *GRPCServer) GetWeatherAt(ctx context.Context, city string, coord *pb.Coordinates, u units, lang string) *pb.Response {
+	data, err := g.fetchOneCall(ctx, coord.GetLat(), coord.GetLon(), lang)
+	if err != nil {
+		g.logger.Printf("request to onecall failed: %s\n", err.Error())
+		return &pb.Response{Response: "weather is not available for this place"}
+	}
+
+	if data.Current.Temp == 0 {
+		return &pb.Response{}
+	}
+
+	name := placeName(city, coord)
+	return weatherResponse(name, name, kelvinToCelsius(data.Current.Temp), data.Current.Humidity, data.Current.WindSpeed, u)
+}
+
+func weatherResponse(label, city string, temp, humidity, windSpeed float64, u units) *pb.Response {
+	indices := comfort.Compute(temp, humidity, windSpeed)
+
+	return &pb.Response{
+		Response: fmt.Sprintf("City: %s, Temp: %.1f %s, Feels like: %.1f %s",
+			label, u.temp(temp), u.tempUnit(), u.temp(indices.FeelsLike), u.tempUnit()),
+		City:      city,
+		Temp:      temp,
+		Humidity:  humidity,
+		WindSpeed: windSpeed,
+		Comfort: &pb.Comfort{
+			FeelsLike: indices.FeelsLike,
+			DewPoint:  indices.DewPoint,
+			HeatIndex: indices.HeatIndex,
+			WindChill: indices.WindChill,
+			Humidex:   indices.Humidex,
+			Clothing:  indices.Clothing.String(),
+		},
+	}
+}
+
+// placeName names a place by its coordinates when the caller gave no name.
+func placeName(city string, coord *pb.Coordinates) string {
+	if city != "" {
+		return city
 	}
+	return fmt.Sprintf("%.2f, %.2f", coord.GetLat(), coord.GetLon())
+}
+
+// Ping checks the provider answers current weather requests.
+func (g *GRPCServer) Ping(ctx context.Context) error {
+	_, err := g.fetchCurrent(ctx, probeCity, "")
+	return err
+}
 
-	return fmt.Sprintf("City: %s, Temp: %.1f", city, kelvinToCelsius(data.Main.Temp))
+func (g *GRPCServer) fetchCurrent(ctx context.Context, city, lang string) (respBody, error) {
+	var data respBody
+	err := g.current.GetJSON(ctx, map[string]string{"city": city, "lang": lang}, &data)
+	if err != nil {
+		return respBody{}, err
+	}
+	if data.Name == "" {
+		return respBody{}, errors.New("city not found")
+	}
+	return data, nil
+}
 
+func (g *GRPCServer) fetchOneCall(ctx context.Context, lat, lon float64, lang string) (oneCallBody, error) {
+	var data oneCallBody
+	err := g.oneCall.GetJSON(ctx, map[string]string{
+		"lat":  strconv.FormatFloat(lat, 'f', -1, 64),
+		"lon":  strconv.FormatFloat(lon, 'f', -1, 64),
+		"lang": lang,
+	}, &data)
+	if err != nil {
+		return oneCallBody{}, err
+	}
+	return data, nil
 }
 
 func kelvinToCelsius(temp float64) float64 {
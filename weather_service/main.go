@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"github.com/joho/godotenv"
 	"github.com/sirupsen/logrus"
+	"time"
+	"weather_service/internal/climate"
 	"weather_service/internal/config"
 	"weather_service/internal/server"
 	"weather_service/internal/service"
 )
 
+// healthInterval is how often the provider is probed for health checks.
+const healthInterval = time.Minute
+
 func main() {
 	cfg := config.Config{}
 	logger := logrus.New()
@@ -22,11 +28,20 @@ func main() {
 		logger.Fatal(err)
 	}
 
-	service := service.NewGRPCServer(&cfg, logger)
+	dataset, err := climate.Load(cfg.ClimateDir)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	if dataset.Len() == 0 {
+		logger.Warningf("no climate data found in %s", cfg.ClimateDir)
+	}
+
+	service := service.NewGRPCServer(&cfg, logger, dataset)
 
 	serv := server.NewWeatherServer(logger, &cfg, service)
 
 	serv.Register()
+	go serv.WatchHealth(context.Background(), healthInterval)
 	serv.Start()
 
 }